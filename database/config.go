@@ -1,6 +1,15 @@
 package database
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// ErrInvalidConfig is returned when database configuration is invalid.
+var ErrInvalidConfig = errors.New("invalid database config")
 
 // Config represents database configuration.
 type Config struct {
@@ -10,8 +19,73 @@ type Config struct {
 	Password         string `mapstructure:"password"`   // pass
 	Database         string `mapstructure:"database"`   // database
 	MigrationsSource string `mapstructure:"migrations"` // database
+
+	TLS                   bool   `mapstructure:"tls"`                      // connect over TLS
+	TLSCAFile             string `mapstructure:"tls_ca_file"`              // CA certificate verifying server, system roots by default
+	TLSInsecureSkipVerify bool   `mapstructure:"tls_insecure_skip_verify"` // skip server certificate verification, development only
+
+	Nodes          []string `mapstructure:"nodes"`           // cluster nodes or gossip seeds as host:port, replaces Host and Port
+	NodePreference string   `mapstructure:"node_preference"` // preferred cluster node: leader, follower, random or readonlyreplica
+
+	KeepAliveInterval time.Duration `mapstructure:"keepalive_interval"` // interval of keepalive pings, server default if zero
+	KeepAliveTimeout  time.Duration `mapstructure:"keepalive_timeout"`  // keepalive ping acknowledgement timeout, server default if zero
+	Deadline          time.Duration `mapstructure:"deadline"`           // default deadline of a single operation, none if zero
+
+	ConnectionString string `mapstructure:"connection_string"` // passed to the driver as is, replaces connection fields above
 }
 
+// DSN returns PostgreSQL data source name, ConnectionString is returned as is if set.
+// TLS verifies server certificate against TLSCAFile or system roots unless TLSInsecureSkipVerify is set.
 func (c *Config) DSN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", c.Host, c.Port, c.Username, c.Password, c.Database)
+	if c.ConnectionString != "" {
+		return c.ConnectionString
+	}
+
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s", quote(c.Host), c.Port, quote(c.Username), quote(c.Password), quote(c.Database))
+	switch {
+	case !c.TLS:
+		dsn += " sslmode=disable"
+	case c.TLSInsecureSkipVerify:
+		dsn += " sslmode=require"
+	default:
+		dsn += " sslmode=verify-full"
+		if c.TLSCAFile != "" {
+			dsn += " sslrootcert=" + quote(c.TLSCAFile)
+		}
+	}
+	return dsn
+}
+
+// quote quotes value of data source name key if needed.
+func quote(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// Validate reports whether configuration is complete and consistent.
+func (c *Config) Validate() error {
+	switch {
+	case c.KeepAliveInterval < 0, c.KeepAliveTimeout < 0, c.Deadline < 0:
+		return errors.Wrap(ErrInvalidConfig, "negative duration")
+	case c.ConnectionString != "":
+		return nil // validated by the driver
+	case c.Host == "" && len(c.Nodes) == 0:
+		return errors.Wrap(ErrInvalidConfig, "host or nodes required")
+	case len(c.Nodes) == 0 && (c.Port <= 0 || c.Port > 65535):
+		return errors.Wrapf(ErrInvalidConfig, "invalid port %d", c.Port)
+	case !c.TLS && (c.TLSCAFile != "" || c.TLSInsecureSkipVerify):
+		return errors.Wrap(ErrInvalidConfig, "tls settings given while tls is disabled")
+	case c.Password != "" && c.Username == "":
+		return errors.Wrap(ErrInvalidConfig, "password given without username")
+	}
+
+	for _, node := range c.Nodes {
+		if node == "" {
+			return errors.Wrap(ErrInvalidConfig, "empty node address")
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// TestConfigValidate verifies inconsistent configurations are rejected.
+func TestConfigValidate(t *testing.T) {
+	var testcases = []struct {
+		config Config
+		err    error
+	}{
+		{config: Config{Host: "localhost", Port: 2113}, err: nil},
+		{config: Config{Nodes: []string{"node1:2113", "node2:2113"}}, err: nil},
+		{config: Config{ConnectionString: "esdb://localhost:2113"}, err: nil},
+		{config: Config{Host: "localhost", Port: 2113, TLS: true, TLSCAFile: "ca.pem"}, err: nil},
+		{config: Config{}, err: ErrInvalidConfig},
+		{config: Config{Host: "localhost"}, err: ErrInvalidConfig},
+		{config: Config{Host: "localhost", Port: 70000}, err: ErrInvalidConfig},
+		{config: Config{Nodes: []string{"node1:2113", ""}}, err: ErrInvalidConfig},
+		{config: Config{Host: "localhost", Port: 2113, TLSCAFile: "ca.pem"}, err: ErrInvalidConfig},
+		{config: Config{Host: "localhost", Port: 2113, TLSInsecureSkipVerify: true}, err: ErrInvalidConfig},
+		{config: Config{Host: "localhost", Port: 2113, Password: "secret"}, err: ErrInvalidConfig},
+		{config: Config{Host: "localhost", Port: 2113, Deadline: -time.Second}, err: ErrInvalidConfig},
+	}
+
+	for i, tt := range testcases {
+		if got, want := tt.config.Validate(), tt.err; !errors.Is(got, want) {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestConfigDSN verifies data source names honour TLS settings.
+func TestConfigDSN(t *testing.T) {
+	var testcases = []struct {
+		config Config
+		dsn    string
+	}{
+		{config: Config{Host: "db", Port: 5432, Username: "app", Password: "secret", Database: "app"}, dsn: "host=db port=5432 user=app password=secret dbname=app sslmode=disable"},
+		{config: Config{Host: "db", Port: 5432, Username: "app", Password: "it's secret", Database: "app"}, dsn: `host=db port=5432 user=app password='it\'s secret' dbname=app sslmode=disable`},
+		{config: Config{Host: "db", Port: 5432, Username: "app", Database: "app", TLS: true}, dsn: "host=db port=5432 user=app password='' dbname=app sslmode=verify-full"},
+		{config: Config{Host: "db", Port: 5432, Username: "app", Database: "app", TLS: true, TLSCAFile: "/etc/ca.pem"}, dsn: "host=db port=5432 user=app password='' dbname=app sslmode=verify-full sslrootcert=/etc/ca.pem"},
+		{config: Config{Host: "db", Port: 5432, Username: "app", Database: "app", TLS: true, TLSInsecureSkipVerify: true}, dsn: "host=db port=5432 user=app password='' dbname=app sslmode=require"},
+		{config: Config{ConnectionString: "postgres://app@db/app?sslmode=verify-ca"}, dsn: "postgres://app@db/app?sslmode=verify-ca"},
+	}
+
+	for i, tt := range testcases {
+		if got, want := tt.config.DSN(), tt.dsn; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package esdb
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deividaspetraitis/go/database"
+	"github.com/deividaspetraitis/go/errors"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// nodePreferences maps node preference names to EventStore node preferences.
+var nodePreferences = map[string]esdb.NodePreference{
+	"leader":          esdb.NodePreference_Leader,
+	"follower":        esdb.NodePreference_Follower,
+	"random":          esdb.NodePreference_Random,
+	"readonlyreplica": esdb.NodePreference_ReadOnlyReplica,
+}
+
+// configuration validates and converts database.Config into EventStore client configuration.
+func configuration(config *database.Config) (*esdb.Configuration, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	if config.ConnectionString != "" {
+		cfg, err := esdb.ParseConnectionString(config.ConnectionString)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", database.ErrInvalidConfig, err)
+		}
+		return cfg, nil
+	}
+
+	nodes := config.Nodes
+	if len(nodes) == 0 {
+		nodes = []string{config.Host + ":" + strconv.Itoa(config.Port)}
+	}
+
+	// credentials and certificates are set directly as connection string does not support escaping
+	cfg, err := esdb.ParseConnectionString("esdb://" + strings.Join(nodes, ","))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", database.ErrInvalidConfig, err)
+	}
+
+	cfg.Username, cfg.Password = config.Username, config.Password
+	cfg.DisableTLS = !config.TLS
+	cfg.SkipCertificateVerification = config.TLSInsecureSkipVerify
+
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading CA file")
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.Wrapf(database.ErrInvalidConfig, "no certificates found in %s", config.TLSCAFile)
+		}
+	}
+
+	if config.NodePreference != "" {
+		preference, ok := nodePreferences[strings.ToLower(config.NodePreference)]
+		if !ok {
+			return nil, errors.Wrapf(database.ErrInvalidConfig, "unknown node preference %q", config.NodePreference)
+		}
+		cfg.NodePreference = preference
+	}
+
+	if config.KeepAliveInterval > 0 {
+		cfg.KeepAliveInterval = config.KeepAliveInterval
+	}
+	if config.KeepAliveTimeout > 0 {
+		cfg.KeepAliveTimeout = config.KeepAliveTimeout
+	}
+
+	return cfg, nil
+}
+
+// withDeadline returns a copy of ctx bounded by client deadline, unless ctx already expires earlier.
+// Deadline is not applied to streaming reads and subscriptions as they outlive the call.
+func (c *Client) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.deadline <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.deadline)
+}
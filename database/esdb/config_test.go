@@ -0,0 +1,78 @@
+package esdb
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/database"
+	"github.com/deividaspetraitis/go/errors"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// TestConfiguration verifies database.Config is converted into EventStore configuration.
+func TestConfiguration(t *testing.T) {
+	cfg, err := configuration(&database.Config{
+		Nodes:             []string{"node1:2113", "node2:2113"},
+		Username:          "admin",
+		Password:          "p@ss:word",
+		TLS:               true,
+		NodePreference:    "Follower",
+		KeepAliveInterval: 30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var nodes []string
+	for _, seed := range cfg.GossipSeeds {
+		nodes = append(nodes, seed.String())
+	}
+	if got, want := nodes, []string{"node1:2113", "node2:2113"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := cfg.Username+" "+cfg.Password, "admin p@ss:word"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := cfg.DisableTLS, false; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := cfg.NodePreference, esdb.NodePreference_Follower; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := cfg.KeepAliveInterval, 30*time.Second; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := cfg.KeepAliveTimeout, 10*time.Second; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestConfigurationErrors verifies invalid configurations are rejected on construction.
+func TestConfigurationErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var testcases = []struct {
+		config database.Config
+		err    error
+	}{
+		{config: database.Config{Host: "localhost", Port: 2113}, err: nil},
+		{config: database.Config{ConnectionString: "esdb://localhost:2113?tls=false"}, err: nil},
+		{config: database.Config{ConnectionString: "http://localhost:2113"}, err: database.ErrInvalidConfig},
+		{config: database.Config{Host: "localhost", Port: 2113, NodePreference: "nearest"}, err: database.ErrInvalidConfig},
+		{config: database.Config{Host: "localhost", Port: 2113, TLS: true, TLSCAFile: invalid}, err: database.ErrInvalidConfig},
+		{config: database.Config{Host: "localhost", Port: 2113, TLS: true, TLSCAFile: invalid + ".missing"}, err: os.ErrNotExist},
+	}
+
+	for i, tt := range testcases {
+		if _, got := configuration(&tt.config); !errors.Is(got, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, got, tt.err)
+		}
+	}
+}
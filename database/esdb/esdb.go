@@ -2,27 +2,62 @@ package esdb
 
 import (
 	"context"
-	"fmt"
 	"math"
+	"strconv"
+	"time"
 
 	"github.com/deividaspetraitis/go/database"
 	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/metrics"
+	"github.com/deividaspetraitis/go/trace"
 
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 )
 
 const (
-	count = math.MaxInt64 // maximum number of events
+	count        = math.MaxInt64 // maximum number of events
+	healthStream = "healthcheck" // stream read to verify server reachability
 )
 
 // DB is EventStore database client.
 type Client struct {
 	*esdb.Client
-	contentType esdb.ContentType
+	contentType   esdb.ContentType
+	namer         StreamNamer
+	maxAppendSize int           // maximum size of a single append in bytes
+	deadline      time.Duration // default deadline of unary operations
 }
 
-func NewClient(config *database.Config) (*Client, error) {
-	cfg, err := esdb.ParseConnectionString(dsn(config))
+// Option is modifier of a Client.
+type Option interface {
+	apply(*Client)
+}
+
+// newOption constructs a new option.
+func newOption(fn func(c *Client)) *option {
+	return &option{applyFn: fn}
+}
+
+// option is an implementation of Option.
+type option struct {
+	applyFn func(c *Client)
+}
+
+// apply implements Option.
+func (o *option) apply(c *Client) {
+	o.applyFn(c)
+}
+
+// WithStreamNamer constructs Option naming aggregate streams using namer, defaults to DefaultStreamNamer.
+func WithStreamNamer(namer StreamNamer) Option {
+	return newOption(func(c *Client) {
+		c.namer = namer
+	})
+}
+
+// NewClient constructs and returns new EventStore client instance, config is validated beforehand.
+func NewClient(config *database.Config, opts ...Option) (*Client, error) {
+	cfg, err := configuration(config)
 	if err != nil {
 		return nil, err
 	}
@@ -32,67 +67,148 @@ func NewClient(config *database.Config) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{
-		Client:      client,
-		contentType: esdb.JsonContentType,
-	}, nil
+	c := &Client{
+		Client:        client,
+		contentType:   ContentTypeJSON,
+		namer:         DefaultStreamNamer,
+		maxAppendSize: defaultMaxAppendSize,
+		deadline:      config.Deadline,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(c)
+		}
+	}
+
+	return c, nil
 }
 
-// dsn parses database.Config into DNS string.
-func dsn(config *database.Config) string {
-	return fmt.Sprintf("esdb://%s:%s@%s:%d?tls=false", config.Username, config.Password, config.Host, config.Port)
+// Check verifies EventStore server is reachable.
+func (c *Client) Check(ctx context.Context) error {
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
+	stream, err := c.ReadStream(ctx, healthStream, esdb.ReadStreamOptions{}, 1)
+	if err != nil {
+		if errors.Is(err, esdb.ErrStreamNotFound) {
+			return nil
+		}
+		return err
+	}
+	stream.Close()
+	return nil
 }
 
 // Save stores given events into EventStore.
-func (c *Client) Save(ctx context.Context, events []*Event) error {
+// Unless WithExpectedRevision is given, stream revision is expected to precede version of the first event.
+// Events exceeding maximum append size are appended in chunks, each chunk expects revision written by the
+// previous one, thus a failed save may leave preceding chunks stored.
+// Events are appended under their ID, saving events already stored at expected revision is a no-op,
+// thus a save may be safely retried.
+func (c *Client) Save(ctx context.Context, events []*Event, opts ...SaveOption) (err error) {
 	if len(events) == 0 {
 		return nil
 	}
 
+	ctx, span := trace.Start(ctx, "esdb.save",
+		trace.WithKind(trace.KindProducer),
+		trace.WithAttribute("esdb.stream", c.stream(events)),
+	)
+	defer func(start time.Time) {
+		operationDuration.ObserveSince(start, "save", metrics.Status(err))
+		if err == nil {
+			eventsTotal.Add(float64(len(events)), "save", events[0].Aggregate)
+		}
+		span.SetError(err)
+		span.Finish()
+	}(time.Now())
+
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
 	var data []esdb.EventData
 	for _, v := range events {
-		data = append(data, esdb.EventData{
-			ContentType: esdb.JsonContentType,
+		e := esdb.EventData{
+			ContentType: c.contentType,
 			EventType:   v.Type,
 			Data:        v.Data,
-			Metadata:    v.Metadata,
-		})
+			Metadata:    c.eventMetadata(ctx, v.Metadata),
+		}
+		copy(e.EventID[:], v.ID[:])
+		data = append(data, e)
 	}
 
-	// last stored version is -1 than oldest event in the list
-	version, err := parseFirstEventVersion(events)
-	if err != nil {
-		return err
-	}
+	s := newSave(opts...)
+	if s.expected == nil {
+		// last stored version is -1 than oldest event in the list
+		version, err := parseFirstEventVersion(events)
+		if err != nil {
+			return err
+		}
 
-	// for the first event skip stream revision check
-	var streamOptions esdb.AppendToStreamOptions
-	if version > 1 {
-		// EventStore events enumeration starts at 0, thus -2.
-		streamOptions.ExpectedRevision = esdb.StreamRevision{Value: uint64(version) - 2}
-	} else if version == 1 {
-		streamOptions.ExpectedRevision = esdb.NoStream{}
+		// for the first event skip stream revision check
+		if version > 1 {
+			// EventStore events enumeration starts at 0, thus -2.
+			s.expected = esdb.StreamRevision{Value: uint64(version) - 2}
+		} else if version == 1 {
+			s.expected = esdb.NoStream{}
+		}
 	}
 
-	if _, err := c.AppendToStream(context.Background(), stream(events), streamOptions, data...); err != nil {
-		return err
+	chunks := chunk(data, c.maxAppendSize)
+	span.SetAttribute("esdb.chunks", strconv.Itoa(len(chunks)))
+
+	expected := s.expected
+	for _, batch := range chunks {
+		result, err := c.AppendToStream(ctx, c.stream(events), esdb.AppendToStreamOptions{
+			ExpectedRevision: expected,
+		}, batch...)
+		if errors.Is(err, esdb.ErrWrongExpectedStreamRevision) {
+			// retried save of already stored events succeeds
+			revision, ok, derr := c.duplicate(ctx, c.stream(events), expected, batch)
+			if derr != nil {
+				return derr
+			}
+			if ok {
+				expected = esdb.StreamRevision{Value: revision}
+				continue
+			}
+		}
+		if err != nil {
+			return streamError(err)
+		}
+		expected = esdb.StreamRevision{Value: result.NextExpectedVersion}
 	}
 
 	return nil
 }
 
 // Get reads an stream of events for specific id and returns Iterator.
+// Observed latency covers opening the stream only, events are read lazily by Iterator.
 func (c *Client) Get(ctx context.Context, id string, aggregate string, afterVersion Version) (*Iterator, error) {
-	stream, err := c.ReadStream(ctx, aggregate+"_"+id, esdb.ReadStreamOptions{
+	start := time.Now()
+	stream, err := c.ReadStream(ctx, c.namer.Stream(aggregate, id), esdb.ReadStreamOptions{
 		From: esdb.StreamRevision{Value: uint64(afterVersion)},
 	}, count)
 	if err != nil {
 		if errors.Is(err, esdb.ErrStreamNotFound) {
+			operationDuration.ObserveSince(start, "get", metrics.Status(nil))
 			return &Iterator{}, nil
 		}
-		return nil, err
+		operationDuration.ObserveSince(start, "get", metrics.Status(err))
+		return nil, streamError(err)
+	}
+	operationDuration.ObserveSince(start, "get", metrics.Status(nil))
+	return &Iterator{stream: stream, aggregate: aggregate, namer: c.namer}, nil
+}
+
+// eventMetadata returns md carrying trace context of ctx propagated to event handlers.
+// Trace context the metadata was produced within is kept, empty metadata of non JSON events is left empty.
+func (c *Client) eventMetadata(ctx context.Context, md []byte) []byte {
+	if len(md) == 0 && c.contentType != ContentTypeJSON {
+		return md
 	}
-	return &Iterator{stream: stream}, nil
+	return trace.InjectMetadata(ctx, md)
 }
 
 // parseFirstEventVersion parses and returns version from the first event in the list.
@@ -1,12 +1,21 @@
 package esdb
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/deividaspetraitis/go/es"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/google/uuid"
+)
 
 // Version represents event version
 type Version uint64
 
 // Event represents Event entity.
 type Event struct {
+	ID          uuid.UUID // unique event id, random id is assigned on save if zero
 	AggregateID string
 	Version     Version
 	Aggregate   string
@@ -14,4 +23,36 @@ type Event struct {
 	Timestamp   time.Time
 	Data        []byte
 	Metadata    []byte
+	Position    Position // position in the $all stream
+}
+
+// Meta returns es.Metadata decoded from event Metadata.
+func (e *Event) Meta() (es.Metadata, error) {
+	return es.ParseMetadata(e.Metadata)
+}
+
+// Context returns a copy of ctx continuing the trace and workflow the event was saved within,
+// events produced within returned context are caused by e.
+func (e *Event) Context(ctx context.Context) context.Context {
+	return es.ContextWithEventMetadata(ctx, e.Metadata)
+}
+
+// newEvent constructs Event out of event recorded in EventStore stream named by namer.
+func newEvent(namer StreamNamer, e *esdb.RecordedEvent) (*Event, error) {
+	aggregate, id, err := namer.Parse(e.StreamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		ID:          uuid.UUID(e.EventID),
+		AggregateID: id,
+		Version:     Version(e.EventNumber),
+		Type:        e.EventType,
+		Aggregate:   aggregate,
+		Timestamp:   e.CreatedDate,
+		Data:        e.Data,
+		Metadata:    e.UserMetadata,
+		Position:    Position{Commit: e.Position.Commit, Prepare: e.Position.Prepare},
+	}, nil
 }
@@ -2,21 +2,34 @@ package esdb
 
 import (
 	"io"
-	"strings"
 
 	"github.com/EventStore/EventStore-Client-Go/esdb"
 )
 
+// eventStream is a stream of events read by Iterator, ended by io.EOF.
+type eventStream interface {
+	Recv() (*esdb.ResolvedEvent, error)
+	Close()
+}
+
 // Iterator represents an iterator allowing to iterate over stream of ledger.Events.
 type Iterator struct {
-	stream *esdb.ReadStream
-	event  *esdb.ResolvedEvent
-	err    error
+	stream    eventStream
+	event     *esdb.ResolvedEvent
+	err       error
+	aggregate string      // aggregate name used for metrics
+	namer     StreamNamer // parses stream names of read events, nil means DefaultStreamNamer
+
+	filter func(e *esdb.ResolvedEvent) bool   // reports whether event is returned, nil returns all events
+	cursor func(e *esdb.ResolvedEvent) string // returns cursor of an event, nil if read is not resumable
+	limit  uint64                             // maximum number of returned events, zero means no limit
+	read   uint64                             // number of returned events
 }
 
 func NewIterator(stream *esdb.ReadStream) *Iterator {
-	i := &Iterator{
-		stream: stream,
+	i := &Iterator{}
+	if stream != nil {
+		i.stream = stream
 	}
 	return i
 }
@@ -31,39 +44,55 @@ func (i *Iterator) Close() {
 
 // Next steps to the next event in the stream.
 func (i *Iterator) Next() bool {
-	if i.stream == nil {
+	if i.stream == nil || (i.limit > 0 && i.read >= i.limit) {
 		return false
 	}
 
-	eventESDB, err := i.stream.Recv()
-	if err != nil {
-		switch err {
-		case io.EOF:
-		default:
-			i.err = err
+	for {
+		eventESDB, err := i.stream.Recv()
+		if err != nil {
+			switch err {
+			case io.EOF:
+			default:
+				i.err = streamError(err)
+			}
+			return false
+		}
+
+		if eventESDB.Event == nil {
+			continue // link to deleted event
+		}
+		if i.filter != nil && !i.filter(eventESDB) {
+			continue
 		}
-		return false
-	}
 
-	i.event = eventESDB
+		i.event = eventESDB
+		i.read++
+		eventsTotal.Inc("get", i.aggregate)
 
-	return true
+		return true
+	}
 }
 
+// Cursor returns cursor of the current event, read started WithCursor continues after it.
+// Empty cursor is returned for reads which can not be resumed.
+func (i *Iterator) Cursor() string {
+	if i.cursor == nil || i.event == nil {
+		return ""
+	}
+	return i.cursor(i.event)
+}
+
+// Error returns error iteration stopped with, ErrStreamDeleted is returned for tombstoned streams.
 func (i *Iterator) Error() error {
 	return i.err
 }
 
 // Value returns the event from the stream.
 func (i *Iterator) Value() (*Event, error) {
-	stream := strings.Split(i.event.Event.StreamID, streamSeparator) // TODO
-	return &Event{
-		AggregateID: stream[1],
-		Version:     Version(i.event.Event.EventNumber),
-		Type:        i.event.Event.EventType,
-		Aggregate:   stream[0],
-		Timestamp:   i.event.Event.CreatedDate,
-		Data:        i.event.Event.Data,
-		Metadata:    i.event.Event.UserMetadata,
-	}, nil
+	namer := i.namer
+	if namer == nil {
+		namer = DefaultStreamNamer
+	}
+	return newEvent(namer, i.event.Event)
 }
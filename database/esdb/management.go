@@ -0,0 +1,285 @@
+package esdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/metrics"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+const (
+	metadataPrefix    = "$$"        // prefixes names of stream metadata streams
+	metadataEventType = "$metadata" // type of events holding stream metadata
+	truncateAttempts  = 3           // attempts to truncate stream with concurrently replaced metadata
+)
+
+// ErrStreamDeleted is returned when reading or writing a tombstoned stream.
+var ErrStreamDeleted = errors.New("stream deleted")
+
+// ACL represents stream access control list, each field lists user or group names granted the permission.
+type ACL struct {
+	Read      []string
+	Write     []string
+	Delete    []string
+	MetaRead  []string
+	MetaWrite []string
+}
+
+// StreamMetadata represents metadata of an aggregate stream controlling its retention and access.
+type StreamMetadata struct {
+	MaxAge         time.Duration          // events older than MaxAge are scavenged, zero means no limit
+	MaxCount       uint64                 // only the last MaxCount events are kept, zero means no limit
+	TruncateBefore *uint64                // events preceding stream revision are scavenged, nil means none
+	ACL            *ACL                   // stream access control list, nil means server default
+	Custom         map[string]interface{} // user defined properties
+}
+
+// streamMetadata is the EventStore representation of StreamMetadata.
+type streamMetadata struct {
+	MaxAge         *uint64  `json:"$maxAge,omitempty"` // seconds
+	MaxCount       *uint64  `json:"$maxCount,omitempty"`
+	TruncateBefore *uint64  `json:"$tb,omitempty"`
+	ACL            *aclJSON `json:"$acl,omitempty"`
+}
+
+// aclJSON is the EventStore representation of ACL.
+type aclJSON struct {
+	Read      roles `json:"$r,omitempty"`
+	Write     roles `json:"$w,omitempty"`
+	Delete    roles `json:"$d,omitempty"`
+	MetaRead  roles `json:"$mr,omitempty"`
+	MetaWrite roles `json:"$mw,omitempty"`
+}
+
+// roles is a list of ACL roles, EventStore encodes a single role as a string.
+type roles []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *roles) UnmarshalJSON(data []byte) error {
+	var role string
+	if err := json.Unmarshal(data, &role); err == nil {
+		*r = roles{role}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(r))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m StreamMetadata) MarshalJSON() ([]byte, error) {
+	props := make(map[string]interface{}, len(m.Custom)+4)
+	for k, v := range m.Custom {
+		props[k] = v
+	}
+
+	if m.MaxAge > 0 {
+		props["$maxAge"] = uint64(math.Ceil(m.MaxAge.Seconds()))
+	}
+	if m.MaxCount > 0 {
+		props["$maxCount"] = m.MaxCount
+	}
+	if m.TruncateBefore != nil {
+		props["$tb"] = *m.TruncateBefore
+	}
+	if m.ACL != nil {
+		props["$acl"] = aclJSON{
+			Read:      m.ACL.Read,
+			Write:     m.ACL.Write,
+			Delete:    m.ACL.Delete,
+			MetaRead:  m.ACL.MetaRead,
+			MetaWrite: m.ACL.MetaWrite,
+		}
+	}
+
+	return json.Marshal(props)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *StreamMetadata) UnmarshalJSON(data []byte) error {
+	var meta streamMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return err
+	}
+
+	var props map[string]interface{}
+	if err := json.Unmarshal(data, &props); err != nil {
+		return err
+	}
+
+	*m = StreamMetadata{TruncateBefore: meta.TruncateBefore}
+	if meta.MaxAge != nil {
+		m.MaxAge = time.Duration(*meta.MaxAge) * time.Second
+	}
+	if meta.MaxCount != nil {
+		m.MaxCount = *meta.MaxCount
+	}
+	if meta.ACL != nil {
+		m.ACL = &ACL{
+			Read:      meta.ACL.Read,
+			Write:     meta.ACL.Write,
+			Delete:    meta.ACL.Delete,
+			MetaRead:  meta.ACL.MetaRead,
+			MetaWrite: meta.ACL.MetaWrite,
+		}
+	}
+
+	for k, v := range props {
+		if strings.HasPrefix(k, "$") {
+			continue // reserved by EventStore
+		}
+		if m.Custom == nil {
+			m.Custom = make(map[string]interface{})
+		}
+		m.Custom[k] = v
+	}
+
+	return nil
+}
+
+// Delete soft-deletes aggregate stream for specific id, deleted stream reads as empty
+// and is recreated by the next Save. Events are removed once database is scavenged.
+func (c *Client) Delete(ctx context.Context, id string, aggregate string) (err error) {
+	defer func(start time.Time) {
+		operationDuration.ObserveSince(start, "delete", metrics.Status(err))
+	}(time.Now())
+
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
+	_, err = c.DeleteStream(ctx, c.namer.Stream(aggregate, id), esdb.DeleteStreamOptions{
+		ExpectedRevision: esdb.Any{},
+	})
+	return streamError(err)
+}
+
+// Tombstone permanently deletes aggregate stream for specific id, any later read or write
+// of the stream results in ErrStreamDeleted.
+func (c *Client) Tombstone(ctx context.Context, id string, aggregate string) (err error) {
+	defer func(start time.Time) {
+		operationDuration.ObserveSince(start, "tombstone", metrics.Status(err))
+	}(time.Now())
+
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
+	_, err = c.TombstoneStream(ctx, c.namer.Stream(aggregate, id), esdb.TombstoneStreamOptions{
+		ExpectedRevision: esdb.Any{},
+	})
+	return streamError(err)
+}
+
+// SetMetadata replaces metadata of aggregate stream for specific id.
+func (c *Client) SetMetadata(ctx context.Context, id string, aggregate string, meta StreamMetadata) (err error) {
+	defer func(start time.Time) {
+		operationDuration.ObserveSince(start, "set_metadata", metrics.Status(err))
+	}(time.Now())
+
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
+	return c.setMetadata(ctx, metadataPrefix+c.namer.Stream(aggregate, id), meta, esdb.Any{})
+}
+
+// setMetadata appends meta to metadata stream expecting it at revision expected.
+func (c *Client) setMetadata(ctx context.Context, stream string, meta StreamMetadata, expected esdb.ExpectedRevision) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.AppendToStream(ctx, stream, esdb.AppendToStreamOptions{
+		ExpectedRevision: expected,
+	}, esdb.EventData{
+		ContentType: esdb.JsonContentType,
+		EventType:   metadataEventType,
+		Data:        data,
+	})
+	return streamError(err)
+}
+
+// Metadata returns metadata of aggregate stream for specific id, zero StreamMetadata is
+// returned for streams without metadata.
+func (c *Client) Metadata(ctx context.Context, id string, aggregate string) (meta *StreamMetadata, err error) {
+	defer func(start time.Time) {
+		operationDuration.ObserveSince(start, "metadata", metrics.Status(err))
+	}(time.Now())
+
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
+	meta, _, err = c.metadata(ctx, metadataPrefix+c.namer.Stream(aggregate, id))
+	return meta, err
+}
+
+// metadata returns the latest metadata stored in metadata stream along with revision
+// of the stream to expect when replacing it.
+func (c *Client) metadata(ctx context.Context, stream string) (*StreamMetadata, esdb.ExpectedRevision, error) {
+	s, err := c.ReadStream(ctx, stream, esdb.ReadStreamOptions{
+		Direction: esdb.Backwards,
+		From:      esdb.End{},
+	}, 1)
+	if errors.Is(err, esdb.ErrStreamNotFound) {
+		return &StreamMetadata{}, esdb.NoStream{}, nil
+	}
+	if err != nil {
+		return nil, nil, streamError(err)
+	}
+	defer s.Close()
+
+	e, err := s.Recv()
+	if errors.Is(err, io.EOF) {
+		return &StreamMetadata{}, esdb.NoStream{}, nil
+	}
+	if err != nil {
+		return nil, nil, streamError(err)
+	}
+
+	meta := new(StreamMetadata)
+	if err := json.Unmarshal(e.OriginalEvent().Data, meta); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing stream metadata")
+	}
+	return meta, esdb.StreamRevision{Value: e.OriginalEvent().EventNumber}, nil
+}
+
+// Truncate marks events of aggregate stream for specific id preceding stream revision
+// before for removal, other metadata of the stream is preserved. Metadata replaced
+// concurrently is read again, at most truncateAttempts times.
+func (c *Client) Truncate(ctx context.Context, id string, aggregate string, before uint64) (err error) {
+	defer func(start time.Time) {
+		operationDuration.ObserveSince(start, "truncate", metrics.Status(err))
+	}(time.Now())
+
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
+	stream := metadataPrefix + c.namer.Stream(aggregate, id)
+	for attempt := 0; attempt < truncateAttempts; attempt++ {
+		meta, expected, err := c.metadata(ctx, stream)
+		if err != nil {
+			return err
+		}
+		meta.TruncateBefore = &before
+
+		err = c.setMetadata(ctx, stream, *meta, expected)
+		if !errors.Is(err, esdb.ErrWrongExpectedStreamRevision) {
+			return err
+		}
+	}
+
+	return errors.Wrapf(esdb.ErrWrongExpectedStreamRevision, "stream %q metadata changed concurrently %d times", stream, truncateAttempts)
+}
+
+// streamError translates EventStore errors of deleted streams into ErrStreamDeleted.
+func streamError(err error) error {
+	var deleted *esdb.StreamDeletedError
+	if errors.As(err, &deleted) {
+		return errors.Wrapf(ErrStreamDeleted, "stream %q", deleted.StreamName)
+	}
+	return err
+}
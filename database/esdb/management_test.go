@@ -0,0 +1,76 @@
+package esdb
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// TestStreamMetadataJSON verifies stream metadata is encoded as expected by EventStore.
+func TestStreamMetadataJSON(t *testing.T) {
+	truncate := uint64(10)
+
+	var testcases = []struct {
+		meta StreamMetadata
+		json string
+	}{
+		{meta: StreamMetadata{}, json: `{}`},
+		{meta: StreamMetadata{MaxAge: time.Hour, MaxCount: 5}, json: `{"$maxAge":3600,"$maxCount":5}`},
+		{meta: StreamMetadata{TruncateBefore: &truncate}, json: `{"$tb":10}`},
+		{meta: StreamMetadata{ACL: &ACL{Read: []string{"$all"}, Write: []string{"admin", "ops"}}}, json: `{"$acl":{"$r":["$all"],"$w":["admin","ops"]}}`},
+		{meta: StreamMetadata{Custom: map[string]interface{}{"owner": "billing"}}, json: `{"owner":"billing"}`},
+	}
+
+	for i, tt := range testcases {
+		data, err := json.Marshal(tt.meta)
+		if err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+		if got, want := string(data), tt.json; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+
+		var meta StreamMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+		if got, want := meta, tt.meta; !reflect.DeepEqual(got, want) {
+			t.Errorf("#%d got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+// TestStreamMetadataSingleRole verifies ACL roles encoded as a string are parsed.
+func TestStreamMetadataSingleRole(t *testing.T) {
+	var meta StreamMetadata
+	if err := json.Unmarshal([]byte(`{"$acl":{"$r":"$all","$d":["admin"]}}`), &meta); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if got, want := meta.ACL, (&ACL{Read: []string{"$all"}, Delete: []string{"admin"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// TestStreamError verifies deleted stream errors are translated.
+func TestStreamError(t *testing.T) {
+	var testcases = []struct {
+		err  error
+		want error
+	}{
+		{err: nil, want: nil},
+		{err: esdb.ErrStreamNotFound, want: esdb.ErrStreamNotFound},
+		{err: &esdb.StreamDeletedError{StreamName: "Order_1"}, want: ErrStreamDeleted},
+		{err: errors.Wrap(&esdb.StreamDeletedError{StreamName: "Order_1"}, "append"), want: ErrStreamDeleted},
+	}
+
+	for i, tt := range testcases {
+		if got, want := streamError(tt.err), tt.want; !errors.Is(got, want) {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package esdb
+
+import (
+	"github.com/deividaspetraitis/go/metrics"
+)
+
+var (
+	operationDuration = metrics.NewHistogram(
+		"esdb_operation_duration_seconds",
+		"Duration of EventStore operations, reads cover opening the stream only.",
+		metrics.DefBuckets,
+		"operation", "status",
+	)
+	eventsTotal = metrics.NewCounter(
+		"esdb_events_total",
+		"Total number of events written to or read from EventStore.",
+		"operation", "aggregate",
+	)
+)
+
+func init() {
+	metrics.Register(operationDuration, eventsTotal)
+}
@@ -0,0 +1,236 @@
+package esdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// NackAction is an action server takes on negatively acknowledged event.
+type NackAction int32
+
+const (
+	NackPark  NackAction = NackAction(esdb.Nack_Park)  // move event to parked messages stream
+	NackRetry NackAction = NackAction(esdb.Nack_Retry) // redeliver event
+	NackSkip  NackAction = NackAction(esdb.Nack_Skip)  // skip event
+	NackStop  NackAction = NackAction(esdb.Nack_Stop)  // stop the subscription
+)
+
+// SubscriptionGroupOption is modifier of persistent subscription group settings.
+type SubscriptionGroupOption interface {
+	apply(*subscriptionGroup)
+}
+
+// newSubscriptionGroupOption constructs a new subscriptionGroupOption.
+func newSubscriptionGroupOption(fn func(g *subscriptionGroup)) *subscriptionGroupOption {
+	return &subscriptionGroupOption{applyFn: fn}
+}
+
+// subscriptionGroupOption is an implementation of SubscriptionGroupOption.
+type subscriptionGroupOption struct {
+	applyFn func(g *subscriptionGroup)
+}
+
+// apply implements SubscriptionGroupOption.
+func (o *subscriptionGroupOption) apply(g *subscriptionGroup) {
+	o.applyFn(g)
+}
+
+// WithMaxRetryCount constructs SubscriptionGroupOption limiting number of event redeliveries before it is parked.
+func WithMaxRetryCount(n int32) SubscriptionGroupOption {
+	return newSubscriptionGroupOption(func(g *subscriptionGroup) {
+		g.settings.MaxRetryCount = n
+	})
+}
+
+// WithBufferSizes constructs SubscriptionGroupOption setting number of live events buffered by server,
+// number of historical events buffered by server and number of events read from stream at once.
+func WithBufferSizes(live, history, readBatch int32) SubscriptionGroupOption {
+	return newSubscriptionGroupOption(func(g *subscriptionGroup) {
+		g.settings.LiveBufferSize = live
+		g.settings.HistoryBufferSize = history
+		g.settings.ReadBatchSize = readBatch
+	})
+}
+
+// WithMessageTimeout constructs SubscriptionGroupOption setting time after which unacknowledged event is retried.
+func WithMessageTimeout(d time.Duration) SubscriptionGroupOption {
+	return newSubscriptionGroupOption(func(g *subscriptionGroup) {
+		g.settings.MessageTimeoutInMs = int32(d.Milliseconds())
+	})
+}
+
+// WithMaxSubscribers constructs SubscriptionGroupOption limiting number of consumers connected to the group.
+func WithMaxSubscribers(n int32) SubscriptionGroupOption {
+	return newSubscriptionGroupOption(func(g *subscriptionGroup) {
+		g.settings.MaxSubscriberCount = n
+	})
+}
+
+// WithStartFrom constructs SubscriptionGroupOption to deliver events following version after,
+// ie. version of the last handled Message, by default group starts with events appended after its creation.
+func WithStartFrom(after Version) SubscriptionGroupOption {
+	return newSubscriptionGroupOption(func(g *subscriptionGroup) {
+		g.from = esdb.StreamRevision{Value: startRevision(after)}
+	})
+}
+
+// startRevision returns revision of the first event following version after. Versions of read
+// events are their stream revisions, persistent subscriptions start at the given revision inclusively.
+func startRevision(after Version) uint64 {
+	return uint64(after) + 1
+}
+
+// WithStartFromBeginning constructs SubscriptionGroupOption to deliver all events of the stream.
+func WithStartFromBeginning() SubscriptionGroupOption {
+	return newSubscriptionGroupOption(func(g *subscriptionGroup) {
+		g.from = esdb.Start{}
+	})
+}
+
+// subscriptionGroup holds settings of persistent subscription group.
+type subscriptionGroup struct {
+	settings esdb.SubscriptionSettings
+	from     esdb.StreamPosition
+}
+
+// newSubscriptionGroup constructs subscriptionGroup applying opts over default settings.
+// Links are resolved by default, thus groups over projected streams ie. categories deliver original events.
+func newSubscriptionGroup(opts ...SubscriptionGroupOption) *subscriptionGroup {
+	g := &subscriptionGroup{
+		settings: esdb.SubscriptionSettingsDefault(),
+		from:     esdb.End{},
+	}
+	g.settings.ResolveLinkTos = true
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(g)
+		}
+	}
+	return g
+}
+
+// CreateSubscriptionGroup creates persistent subscription group consuming stream.
+func (c *Client) CreateSubscriptionGroup(ctx context.Context, stream, group string, opts ...SubscriptionGroupOption) error {
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
+	g := newSubscriptionGroup(opts...)
+	return c.CreatePersistentSubscription(ctx, stream, group, esdb.PersistentStreamSubscriptionOptions{
+		Settings: &g.settings,
+		From:     g.from,
+	})
+}
+
+// UpdateSubscriptionGroup replaces settings of persistent subscription group consuming stream.
+func (c *Client) UpdateSubscriptionGroup(ctx context.Context, stream, group string, opts ...SubscriptionGroupOption) error {
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
+	g := newSubscriptionGroup(opts...)
+	return c.UpdatePersistentStreamSubscription(ctx, stream, group, esdb.PersistentStreamSubscriptionOptions{
+		Settings: &g.settings,
+		From:     g.from,
+	})
+}
+
+// DeleteSubscriptionGroup deletes persistent subscription group consuming stream.
+func (c *Client) DeleteSubscriptionGroup(ctx context.Context, stream, group string) error {
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+
+	return c.DeletePersistentSubscription(ctx, stream, group, esdb.DeletePersistentSubscriptionOptions{})
+}
+
+// ConnectSubscriptionGroup connects to persistent subscription group as one of competing consumers.
+// At most bufferSize events are in flight before they are acknowledged, zero means default of 10.
+func (c *Client) ConnectSubscriptionGroup(ctx context.Context, stream, group string, bufferSize uint32) (*PersistentSubscription, error) {
+	sub, err := c.ConnectToPersistentSubscription(ctx, stream, group, esdb.ConnectToPersistentSubscriptionOptions{
+		BatchSize: bufferSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PersistentSubscription{sub: sub, namer: c.namer}, nil
+}
+
+// PersistentSubscription is a connection to persistent subscription group.
+type PersistentSubscription struct {
+	sub     *esdb.PersistentSubscription
+	namer   StreamNamer // parses stream names of delivered events
+	message *Message
+	err     error
+}
+
+// Next blocks until the next event is delivered, it returns false once subscription is dropped
+// or events which can not be handled fail to be negatively acknowledged.
+func (s *PersistentSubscription) Next() bool {
+	for {
+		e := s.sub.Recv()
+		switch {
+		case e.SubscriptionDropped != nil:
+			s.err = streamError(e.SubscriptionDropped.Error)
+			return false
+		case e.EventAppeared != nil:
+			if e.EventAppeared.Event == nil {
+				// link to deleted event can not be handled
+				if err := s.sub.Nack("event not found", esdb.Nack_Skip, e.EventAppeared); err != nil {
+					s.err = errors.Wrap(err, "skipping event not found")
+					return false
+				}
+				continue
+			}
+			event, err := newEvent(s.namer, e.EventAppeared.Event)
+			if err != nil {
+				// events of foreign streams can not be handled, keep them for inspection
+				if err := s.sub.Nack(err.Error(), esdb.Nack_Park, e.EventAppeared); err != nil {
+					s.err = errors.Wrap(err, "parking foreign event")
+					return false
+				}
+				continue
+			}
+			s.message = &Message{
+				Event:    event,
+				sub:      s.sub,
+				resolved: e.EventAppeared,
+			}
+			eventsTotal.Inc("persistent", s.message.Aggregate)
+			return true
+		}
+	}
+}
+
+// Value returns message subscription stepped to.
+func (s *PersistentSubscription) Value() *Message {
+	return s.message
+}
+
+// Error returns error subscription was dropped or stopped with.
+func (s *PersistentSubscription) Error() error {
+	return s.err
+}
+
+// Close closes the subscription, unacknowledged events are redelivered to other consumers.
+func (s *PersistentSubscription) Close() error {
+	return s.sub.Close()
+}
+
+// Message is an event delivered by persistent subscription which must be acknowledged once handled.
+type Message struct {
+	*Event
+	sub      *esdb.PersistentSubscription
+	resolved *esdb.ResolvedEvent
+}
+
+// Ack acknowledges message was handled.
+func (m *Message) Ack() error {
+	return m.sub.Ack(m.resolved)
+}
+
+// Nack negatively acknowledges message asking server to take action.
+func (m *Message) Nack(action NackAction, reason string) error {
+	return m.sub.Nack(reason, esdb.Nack_Action(action), m.resolved)
+}
@@ -0,0 +1,101 @@
+package esdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// TestNewSubscriptionGroup verifies options are applied over default group settings.
+func TestNewSubscriptionGroup(t *testing.T) {
+	defaults := esdb.SubscriptionSettingsDefault()
+	defaults.ResolveLinkTos = true
+
+	var testcases = []struct {
+		opts []SubscriptionGroupOption
+
+		settings func(s *esdb.SubscriptionSettings)
+		from     esdb.StreamPosition
+	}{
+		{
+			opts:     nil,
+			settings: func(s *esdb.SubscriptionSettings) {},
+			from:     esdb.End{},
+		},
+		{
+			opts:     []SubscriptionGroupOption{nil, WithMaxRetryCount(3), WithMaxSubscribers(2)},
+			settings: func(s *esdb.SubscriptionSettings) { s.MaxRetryCount, s.MaxSubscriberCount = 3, 2 },
+			from:     esdb.End{},
+		},
+		{
+			opts: []SubscriptionGroupOption{WithBufferSizes(1, 2, 3), WithMessageTimeout(5 * time.Second)},
+			settings: func(s *esdb.SubscriptionSettings) {
+				s.LiveBufferSize, s.HistoryBufferSize, s.ReadBatchSize = 1, 2, 3
+				s.MessageTimeoutInMs = 5000
+			},
+			from: esdb.End{},
+		},
+		{
+			opts:     []SubscriptionGroupOption{WithStartFrom(0)},
+			settings: func(s *esdb.SubscriptionSettings) {},
+			from:     esdb.StreamRevision{Value: 1},
+		},
+		{
+			opts:     []SubscriptionGroupOption{WithStartFrom(7)},
+			settings: func(s *esdb.SubscriptionSettings) {},
+			from:     esdb.StreamRevision{Value: 8},
+		},
+		{
+			opts:     []SubscriptionGroupOption{WithStartFrom(7), WithStartFromBeginning()},
+			settings: func(s *esdb.SubscriptionSettings) {},
+			from:     esdb.Start{},
+		},
+	}
+
+	for i, tt := range testcases {
+		g := newSubscriptionGroup(tt.opts...)
+
+		want := defaults
+		tt.settings(&want)
+		if got := g.settings; !reflect.DeepEqual(got, want) {
+			t.Errorf("#%d got %+v, want %+v", i, got, want)
+		}
+		if got, want := g.from, tt.from; !reflect.DeepEqual(got, want) {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestNackAction verifies nack actions map onto EventStore actions.
+func TestNackAction(t *testing.T) {
+	var testcases = []struct {
+		action NackAction
+		want   esdb.Nack_Action
+	}{
+		{action: NackPark, want: esdb.Nack_Park},
+		{action: NackRetry, want: esdb.Nack_Retry},
+		{action: NackSkip, want: esdb.Nack_Skip},
+		{action: NackStop, want: esdb.Nack_Stop},
+	}
+
+	for i, tt := range testcases {
+		if got, want := esdb.Nack_Action(tt.action), tt.want; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestStartRevision verifies groups started from version of a delivered event start right after the event.
+func TestStartRevision(t *testing.T) {
+	for i, revision := range []uint64{0, 1, 7} {
+		e, err := newEvent(DefaultStreamNamer, &esdb.RecordedEvent{StreamID: "Order_1", EventNumber: revision})
+		if err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+		if got, want := startRevision(e.Version), revision+1; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
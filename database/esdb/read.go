@@ -0,0 +1,436 @@
+package esdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/metrics"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// categoryPrefix prefixes names of category projection streams.
+const categoryPrefix = "$ce-"
+
+// ErrInvalidCursor is returned when read cursor can not be parsed.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Position is a position of an event in the $all stream.
+type Position struct {
+	Commit  uint64
+	Prepare uint64
+}
+
+// String returns position encoded as cursor.
+func (p Position) String() string {
+	return fmt.Sprintf("%d/%d", p.Commit, p.Prepare)
+}
+
+// ParsePosition parses position encoded by Position.String.
+func ParsePosition(s string) (Position, error) {
+	commit, prepare, ok := strings.Cut(s, "/")
+	if !ok {
+		return Position{}, ErrInvalidCursor
+	}
+	c, err := strconv.ParseUint(commit, 10, 64)
+	if err != nil {
+		return Position{}, ErrInvalidCursor
+	}
+	p, err := strconv.ParseUint(prepare, 10, 64)
+	if err != nil {
+		return Position{}, ErrInvalidCursor
+	}
+	return Position{Commit: c, Prepare: p}, nil
+}
+
+// ReadOption is modifier of a read.
+type ReadOption interface {
+	apply(*read)
+}
+
+// newReadOption constructs a new readOption.
+func newReadOption(fn func(r *read)) *readOption {
+	return &readOption{applyFn: fn}
+}
+
+// readOption is an implementation of ReadOption.
+type readOption struct {
+	applyFn func(r *read)
+}
+
+// apply implements ReadOption.
+func (o *readOption) apply(r *read) {
+	o.applyFn(r)
+}
+
+// WithBackwards constructs ReadOption reading events from the newest to the oldest.
+func WithBackwards() ReadOption {
+	return newReadOption(func(r *read) {
+		r.backwards = true
+	})
+}
+
+// WithCursor constructs ReadOption continuing read after event cursor was returned for by Iterator.Cursor.
+func WithCursor(cursor string) ReadOption {
+	return newReadOption(func(r *read) {
+		r.cursor = cursor
+	})
+}
+
+// WithLimit constructs ReadOption limiting number of events returned by the read.
+func WithLimit(n uint64) ReadOption {
+	return newReadOption(func(r *read) {
+		r.limit = n
+	})
+}
+
+// WithEventTypes constructs ReadOption returning only events having any of given types.
+func WithEventTypes(types ...string) ReadOption {
+	return newReadOption(func(r *read) {
+		r.eventTypes = append(r.eventTypes, types...)
+	})
+}
+
+// WithStreamPrefixes constructs ReadOption returning only events of streams having any of given prefixes.
+func WithStreamPrefixes(prefixes ...string) ReadOption {
+	return newReadOption(func(r *read) {
+		r.streamPrefixes = append(r.streamPrefixes, prefixes...)
+	})
+}
+
+// read holds settings of a read.
+type read struct {
+	backwards      bool
+	cursor         string
+	limit          uint64
+	eventTypes     []string
+	streamPrefixes []string
+}
+
+// newRead constructs read applying opts.
+func newRead(opts ...ReadOption) *read {
+	r := &read{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(r)
+		}
+	}
+	return r
+}
+
+// direction returns direction of the read.
+func (r *read) direction() esdb.Direction {
+	if r.backwards {
+		return esdb.Backwards
+	}
+	return esdb.Forwards
+}
+
+// match reports whether e passes read filters.
+func (r *read) match(e *esdb.RecordedEvent) bool {
+	if len(r.eventTypes) > 0 && !contains(r.eventTypes, e.EventType) {
+		return false
+	}
+	if len(r.streamPrefixes) > 0 {
+		for _, prefix := range r.streamPrefixes {
+			if strings.HasPrefix(e.StreamID, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// allPosition returns position of $all the read starts from and position of the cursor event
+// to skip as reads start at the cursor inclusively, skip is nil for reads without cursor.
+func (r *read) allPosition() (from esdb.AllPosition, skip *esdb.Position, err error) {
+	from = esdb.Start{}
+	if r.backwards {
+		from = esdb.End{}
+	}
+
+	if r.cursor == "" {
+		return from, nil, nil
+	}
+
+	p, err := ParsePosition(r.cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	skip = &esdb.Position{Commit: p.Commit, Prepare: p.Prepare}
+	return *skip, skip, nil
+}
+
+// streamPosition returns stream revision the read starts from, done reports whether
+// nothing is left to read following the cursor.
+func (r *read) streamPosition() (from esdb.StreamPosition, done bool, err error) {
+	from = esdb.Start{}
+	if r.backwards {
+		from = esdb.End{}
+	}
+
+	if r.cursor == "" {
+		return from, false, nil
+	}
+
+	revision, err := strconv.ParseUint(r.cursor, 10, 64)
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	switch {
+	case !r.backwards:
+		return esdb.StreamRevision{Value: revision + 1}, false, nil
+	case revision == 0:
+		return nil, true, nil // nothing precedes the first event
+	default:
+		return esdb.StreamRevision{Value: revision - 1}, false, nil
+	}
+}
+
+// subscriptionFilter returns server side filter of the read, nil is returned for reads without filters.
+// Stream prefixes take precedence over event types, the other filter is evaluated by match.
+// The filter passes last event too, thus subscription reaches it even if no other events pass.
+func (r *read) subscriptionFilter(last *esdb.RecordedEvent) *esdb.SubscriptionFilter {
+	switch {
+	case len(r.streamPrefixes) > 0:
+		return &esdb.SubscriptionFilter{
+			Type:  esdb.StreamFilterType,
+			Regex: "^(?:" + alternation(r.streamPrefixes) + ")|^" + regexp.QuoteMeta(last.StreamID) + "$",
+		}
+	case len(r.eventTypes) > 0:
+		return &esdb.SubscriptionFilter{
+			Type:  esdb.EventFilterType,
+			Regex: "^(?:" + alternation(r.eventTypes) + "|" + regexp.QuoteMeta(last.EventType) + ")$",
+		}
+	}
+	return nil
+}
+
+// alternation returns regular expression alternation matching any of values literally.
+func alternation(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = regexp.QuoteMeta(v)
+	}
+	return strings.Join(quoted, "|")
+}
+
+// allFilter returns Iterator filter of $all read skipping cursor event, system events and
+// events of streams not holding aggregates.
+func (c *Client) allFilter(r *read, skip *esdb.Position) func(e *esdb.ResolvedEvent) bool {
+	return func(e *esdb.ResolvedEvent) bool {
+		recorded := e.Event
+		if skip != nil && recorded.Position == *skip {
+			return false
+		}
+		if strings.HasPrefix(recorded.EventType, "$") || !c.isAggregateStream(recorded.StreamID) {
+			return false
+		}
+		return r.match(recorded)
+	}
+}
+
+// ReadAllEvents reads events of all aggregates from the $all stream, system events are skipped.
+//
+// Forward reads filtered WithEventTypes or WithStreamPrefixes are filtered by server using
+// subscription to $all ending at the position $all was at when read started.
+// Backward reads can not be served by subscriptions, their filters are evaluated while iterating.
+func (c *Client) ReadAllEvents(ctx context.Context, opts ...ReadOption) (*Iterator, error) {
+	r := newRead(opts...)
+
+	from, skip, err := r.allPosition()
+	if err != nil {
+		return nil, err
+	}
+
+	var stream eventStream
+	start := time.Now()
+	if !r.backwards && (len(r.eventTypes) > 0 || len(r.streamPrefixes) > 0) {
+		stream, err = c.subscribeAll(ctx, r, from)
+	} else {
+		stream, err = c.ReadAll(ctx, esdb.ReadAllOptions{
+			Direction: r.direction(),
+			From:      from,
+		}, count)
+	}
+	operationDuration.ObserveSince(start, "read_all", metrics.Status(err))
+	if err != nil {
+		return nil, err
+	}
+	if stream == nil {
+		return &Iterator{}, nil
+	}
+
+	return &Iterator{
+		stream:    stream,
+		aggregate: "$all",
+		namer:     c.namer,
+		limit:     r.limit,
+		filter:    c.allFilter(r, skip),
+		cursor: func(e *esdb.ResolvedEvent) string {
+			return Position{Commit: e.Event.Position.Commit, Prepare: e.Event.Position.Prepare}.String()
+		},
+	}, nil
+}
+
+// subscribeAll subscribes to $all from position filtering events by server until the last event
+// appended before the call, nil stream is returned if no events follow from.
+func (c *Client) subscribeAll(ctx context.Context, r *read, from esdb.AllPosition) (eventStream, error) {
+	last, err := c.ReadAll(ctx, esdb.ReadAllOptions{
+		Direction: esdb.Backwards,
+		From:      esdb.End{},
+	}, 1)
+	if err != nil {
+		return nil, err
+	}
+	e, err := last.Recv()
+	last.Close()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := from.(esdb.Position); ok && !before(p, e.Event.Position) {
+		return nil, nil // nothing follows the cursor
+	}
+
+	sub, err := c.SubscribeToAll(ctx, esdb.SubscribeToAllOptions{
+		From:   from,
+		Filter: r.subscriptionFilter(e.Event),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &subscriptionStream{sub: sub, end: e.Event.Position}, nil
+}
+
+// subscription is a subscription to $all.
+type subscription interface {
+	Recv() *esdb.SubscriptionEvent
+	Close() error
+}
+
+// subscriptionStream adapts subscription into eventStream ending at position end.
+type subscriptionStream struct {
+	sub  subscription
+	end  esdb.Position
+	done bool
+}
+
+// Recv implements eventStream.
+func (s *subscriptionStream) Recv() (*esdb.ResolvedEvent, error) {
+	for !s.done {
+		e := s.sub.Recv()
+		switch {
+		case e.SubscriptionDropped != nil:
+			s.done = true
+			if e.SubscriptionDropped.Error != nil {
+				return nil, e.SubscriptionDropped.Error
+			}
+			return nil, errors.New("subscription dropped")
+		case e.CheckPointReached != nil:
+			s.done = !before(*e.CheckPointReached, s.end)
+		case e.EventAppeared != nil:
+			p := e.EventAppeared.OriginalEvent().Position
+			if before(s.end, p) {
+				s.done = true
+				continue
+			}
+			s.done = p == s.end
+			return e.EventAppeared, nil
+		}
+	}
+	return nil, io.EOF
+}
+
+// Close implements eventStream.
+func (s *subscriptionStream) Close() {
+	s.sub.Close()
+}
+
+// before reports whether position a precedes b in $all.
+func before(a, b esdb.Position) bool {
+	if a.Commit != b.Commit {
+		return a.Commit < b.Commit
+	}
+	return a.Prepare < b.Prepare
+}
+
+// ReadCategory reads events of all aggregates of given type from the category projection stream.
+// It requires EventStore system projections enabled and client configured with CategoryStreamNamer.
+func (c *Client) ReadCategory(ctx context.Context, aggregate string, opts ...ReadOption) (*Iterator, error) {
+	return c.readStream(ctx, categoryPrefix+aggregate, aggregate, "read_category", opts...)
+}
+
+// ReadAggregate reads events of aggregate stream for specific id, unlike Get it supports reading
+// backwards, limits, filters and cursors.
+func (c *Client) ReadAggregate(ctx context.Context, id string, aggregate string, opts ...ReadOption) (*Iterator, error) {
+	return c.readStream(ctx, c.namer.Stream(aggregate, id), aggregate, "read_aggregate", opts...)
+}
+
+// readStream reads events of stream resolving links, stream revisions are used as cursors.
+// Observed latency covers opening the stream only, events are read lazily by Iterator.
+func (c *Client) readStream(ctx context.Context, stream, aggregate, operation string, opts ...ReadOption) (*Iterator, error) {
+	r := newRead(opts...)
+
+	from, done, err := r.streamPosition()
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		return &Iterator{}, nil
+	}
+
+	start := time.Now()
+	s, err := c.ReadStream(ctx, stream, esdb.ReadStreamOptions{
+		Direction:      r.direction(),
+		From:           from,
+		ResolveLinkTos: true,
+	}, count)
+	if err != nil {
+		if errors.Is(err, esdb.ErrStreamNotFound) {
+			operationDuration.ObserveSince(start, operation, metrics.Status(nil))
+			return &Iterator{}, nil
+		}
+		operationDuration.ObserveSince(start, operation, metrics.Status(err))
+		return nil, streamError(err)
+	}
+	operationDuration.ObserveSince(start, operation, metrics.Status(nil))
+
+	return &Iterator{
+		stream:    s,
+		aggregate: aggregate,
+		namer:     c.namer,
+		limit:     r.limit,
+		filter: func(e *esdb.ResolvedEvent) bool {
+			return r.match(e.Event)
+		},
+		cursor: func(e *esdb.ResolvedEvent) string {
+			return strconv.FormatUint(e.OriginalEvent().EventNumber, 10)
+		},
+	}, nil
+}
+
+// isAggregateStream reports whether stream holds events of an aggregate.
+func (c *Client) isAggregateStream(stream string) bool {
+	_, _, err := c.namer.Parse(stream)
+	return err == nil
+}
+
+// contains reports whether values contain v.
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
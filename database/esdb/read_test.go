@@ -0,0 +1,212 @@
+package esdb
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/deividaspetraitis/go/errors"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// TestParsePosition verifies positions round-trip through cursors.
+func TestParsePosition(t *testing.T) {
+	var testcases = []struct {
+		cursor string
+
+		position Position
+		err      error
+	}{
+		{cursor: "10/8", position: Position{Commit: 10, Prepare: 8}},
+		{cursor: "0/0", position: Position{}},
+		{cursor: "10", err: ErrInvalidCursor},
+		{cursor: "a/1", err: ErrInvalidCursor},
+	}
+
+	for i, tt := range testcases {
+		p, err := ParsePosition(tt.cursor)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if got, want := p, tt.position; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if tt.err == nil && p.String() != tt.cursor {
+			t.Errorf("#%d got %v, want %v", i, p.String(), tt.cursor)
+		}
+	}
+}
+
+// TestReadFilter verifies read filters by event type and stream prefix.
+func TestReadFilter(t *testing.T) {
+	var testcases = []struct {
+		opts  []ReadOption
+		event esdb.RecordedEvent
+		match bool
+	}{
+		{event: esdb.RecordedEvent{StreamID: "Order_1", EventType: "Placed"}, match: true},
+		{opts: []ReadOption{WithEventTypes("Placed")}, event: esdb.RecordedEvent{StreamID: "Order_1", EventType: "Placed"}, match: true},
+		{opts: []ReadOption{WithEventTypes("Shipped")}, event: esdb.RecordedEvent{StreamID: "Order_1", EventType: "Placed"}, match: false},
+		{opts: []ReadOption{WithStreamPrefixes("Invoice", "Order")}, event: esdb.RecordedEvent{StreamID: "Order_1"}, match: true},
+		{opts: []ReadOption{WithStreamPrefixes("Invoice")}, event: esdb.RecordedEvent{StreamID: "Order_1"}, match: false},
+	}
+
+	for i, tt := range testcases {
+		if got, want := newRead(tt.opts...).match(&tt.event), tt.match; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestStreamPosition verifies stream reads continue right after the cursor event.
+func TestStreamPosition(t *testing.T) {
+	var testcases = []struct {
+		opts []ReadOption
+
+		from esdb.StreamPosition
+		done bool
+		err  error
+	}{
+		{from: esdb.Start{}},
+		{opts: []ReadOption{WithBackwards()}, from: esdb.End{}},
+		{opts: []ReadOption{WithCursor("5")}, from: esdb.StreamRevision{Value: 6}},
+		{opts: []ReadOption{WithCursor("0")}, from: esdb.StreamRevision{Value: 1}},
+		{opts: []ReadOption{WithBackwards(), WithCursor("5")}, from: esdb.StreamRevision{Value: 4}},
+		{opts: []ReadOption{WithBackwards(), WithCursor("0")}, done: true},
+		{opts: []ReadOption{WithCursor("1/1")}, err: ErrInvalidCursor},
+	}
+
+	for i, tt := range testcases {
+		from, done, err := newRead(tt.opts...).streamPosition()
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if got, want := from, tt.from; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := done, tt.done; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestReadAllCursor verifies $all reads start at the cursor event and skip it.
+func TestReadAllCursor(t *testing.T) {
+	c := &Client{namer: DefaultStreamNamer}
+
+	var testcases = []struct {
+		opts  []ReadOption
+		event esdb.RecordedEvent
+
+		from  esdb.AllPosition
+		match bool
+		err   error
+	}{
+		{event: esdb.RecordedEvent{StreamID: "Order_1", EventType: "Placed", Position: esdb.Position{Commit: 10, Prepare: 8}}, from: esdb.Start{}, match: true},
+		{opts: []ReadOption{WithBackwards()}, event: esdb.RecordedEvent{StreamID: "Order_1", EventType: "Placed"}, from: esdb.End{}, match: true},
+		{opts: []ReadOption{WithCursor("10/8")}, event: esdb.RecordedEvent{StreamID: "Order_1", EventType: "Placed", Position: esdb.Position{Commit: 10, Prepare: 8}}, from: esdb.Position{Commit: 10, Prepare: 8}, match: false},
+		{opts: []ReadOption{WithCursor("10/8")}, event: esdb.RecordedEvent{StreamID: "Order_1", EventType: "Placed", Position: esdb.Position{Commit: 12, Prepare: 12}}, from: esdb.Position{Commit: 10, Prepare: 8}, match: true},
+		{opts: []ReadOption{WithCursor("10/8")}, event: esdb.RecordedEvent{StreamID: "$stats", EventType: "$statsCollected", Position: esdb.Position{Commit: 12, Prepare: 12}}, from: esdb.Position{Commit: 10, Prepare: 8}, match: false},
+		{opts: []ReadOption{WithCursor("10")}, err: ErrInvalidCursor},
+	}
+
+	for i, tt := range testcases {
+		r := newRead(tt.opts...)
+		from, skip, err := r.allPosition()
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if got, want := from, tt.from; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if err != nil {
+			continue
+		}
+		if got, want := c.allFilter(r, skip)(&esdb.ResolvedEvent{Event: &tt.event}), tt.match; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestSubscriptionFilter verifies read filters are translated into server side filters passing the last event.
+func TestSubscriptionFilter(t *testing.T) {
+	last := &esdb.RecordedEvent{StreamID: "$stats-1", EventType: "$statsCollected"}
+
+	var testcases = []struct {
+		opts   []ReadOption
+		filter *esdb.SubscriptionFilter
+	}{
+		{filter: nil},
+		{opts: []ReadOption{WithEventTypes("Placed", "Order.Shipped")}, filter: &esdb.SubscriptionFilter{Type: esdb.EventFilterType, Regex: `^(?:Placed|Order\.Shipped|\$statsCollected)$`}},
+		{opts: []ReadOption{WithEventTypes("Placed"), WithStreamPrefixes("Order_")}, filter: &esdb.SubscriptionFilter{Type: esdb.StreamFilterType, Regex: `^(?:Order_)|^\$stats-1$`}},
+	}
+
+	for i, tt := range testcases {
+		if got, want := newRead(tt.opts...).subscriptionFilter(last), tt.filter; !reflect.DeepEqual(got, want) {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// subscriptionFunc implements subscription delivering events returned by fn.
+type subscriptionFunc func() *esdb.SubscriptionEvent
+
+// Recv implements subscription.
+func (fn subscriptionFunc) Recv() *esdb.SubscriptionEvent { return fn() }
+
+// Close implements subscription.
+func (fn subscriptionFunc) Close() error { return nil }
+
+// TestSubscriptionStream verifies subscription streams end once the end position is reached.
+func TestSubscriptionStream(t *testing.T) {
+	event := func(commit uint64) *esdb.SubscriptionEvent {
+		return &esdb.SubscriptionEvent{EventAppeared: &esdb.ResolvedEvent{Event: &esdb.RecordedEvent{Position: esdb.Position{Commit: commit, Prepare: commit}}}}
+	}
+	checkpoint := func(commit uint64) *esdb.SubscriptionEvent {
+		return &esdb.SubscriptionEvent{CheckPointReached: &esdb.Position{Commit: commit, Prepare: commit}}
+	}
+
+	var testcases = []struct {
+		events []*esdb.SubscriptionEvent
+
+		commits []uint64
+		err     error
+	}{
+		{events: []*esdb.SubscriptionEvent{event(1), event(2), event(5)}, commits: []uint64{1, 2, 5}, err: io.EOF},
+		{events: []*esdb.SubscriptionEvent{event(1), checkpoint(3), event(6)}, commits: []uint64{1}, err: io.EOF},
+		{events: []*esdb.SubscriptionEvent{event(1), checkpoint(5)}, commits: []uint64{1}, err: io.EOF},
+		{events: []*esdb.SubscriptionEvent{event(1), {SubscriptionDropped: &esdb.SubscriptionDropped{Error: io.ErrUnexpectedEOF}}}, commits: []uint64{1}, err: io.ErrUnexpectedEOF},
+	}
+
+	for i, tt := range testcases {
+		events := tt.events
+		s := &subscriptionStream{
+			sub: subscriptionFunc(func() *esdb.SubscriptionEvent {
+				e := events[0]
+				events = events[1:]
+				return e
+			}),
+			end: esdb.Position{Commit: 5, Prepare: 5},
+		}
+
+		var (
+			commits []uint64
+			err     error
+		)
+		for {
+			var e *esdb.ResolvedEvent
+			if e, err = s.Recv(); err != nil {
+				break
+			}
+			commits = append(commits, e.Event.Position.Commit)
+		}
+
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if got, want := commits, tt.commits; !reflect.DeepEqual(got, want) {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
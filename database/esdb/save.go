@@ -0,0 +1,169 @@
+package esdb
+
+import (
+	"context"
+	"io"
+
+	"github.com/deividaspetraitis/go/errors"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultMaxAppendSize is the default size limit of a single append, zero disables chunking.
+	defaultMaxAppendSize = 0
+
+	// eventOverhead is the size reserved for each event on top of its type, data and metadata,
+	// it covers event ID, system metadata such as content type and message framing.
+	eventOverhead = 512
+)
+
+// Content types of event payloads.
+const (
+	ContentTypeJSON   = esdb.JsonContentType
+	ContentTypeBinary = esdb.BinaryContentType // e.g. protobuf encoded payloads
+)
+
+// WithContentType constructs Option setting content type of saved event payloads, defaults to ContentTypeJSON.
+func WithContentType(contentType esdb.ContentType) Option {
+	return newOption(func(c *Client) {
+		c.contentType = contentType
+	})
+}
+
+// WithMaxAppendSize constructs Option limiting total size of events sent in a single append.
+// Larger batches are split into chunks, zero disables chunking which is the default.
+// Size should be below the server limit, e.g. 1MiB EventStore default.
+func WithMaxAppendSize(size int) Option {
+	return newOption(func(c *Client) {
+		c.maxAppendSize = size
+	})
+}
+
+// ExpectedRevision is an expectation about the state of the stream checked when events are saved.
+type ExpectedRevision struct {
+	revision esdb.ExpectedRevision
+}
+
+// Expected revisions not tied to a specific revision.
+var (
+	AnyRevision  = ExpectedRevision{revision: esdb.Any{}}          // stream may be in any state
+	NoStream     = ExpectedRevision{revision: esdb.NoStream{}}     // stream must not exist
+	StreamExists = ExpectedRevision{revision: esdb.StreamExists{}} // stream must exist
+)
+
+// Revision returns ExpectedRevision requiring the last event of the stream to be at revision.
+func Revision(revision uint64) ExpectedRevision {
+	return ExpectedRevision{revision: esdb.StreamRevision{Value: revision}}
+}
+
+// SaveOption is modifier of a save.
+type SaveOption interface {
+	apply(*save)
+}
+
+// newSaveOption constructs a new saveOption.
+func newSaveOption(fn func(s *save)) *saveOption {
+	return &saveOption{applyFn: fn}
+}
+
+// saveOption is an implementation of SaveOption.
+type saveOption struct {
+	applyFn func(s *save)
+}
+
+// apply implements SaveOption.
+func (o *saveOption) apply(s *save) {
+	o.applyFn(s)
+}
+
+// WithExpectedRevision constructs SaveOption checking stream state against revision instead of
+// the one derived from the version of the first saved event.
+func WithExpectedRevision(revision ExpectedRevision) SaveOption {
+	return newSaveOption(func(s *save) {
+		s.expected = revision.revision
+	})
+}
+
+// save holds settings of a save.
+type save struct {
+	expected esdb.ExpectedRevision // nil derives expectation from events
+}
+
+// newSave constructs a new save applying given options.
+func newSave(opts ...SaveOption) *save {
+	s := new(save)
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(s)
+		}
+	}
+	return s
+}
+
+// chunk splits events into consecutive chunks of at most size bytes counting eventOverhead
+// for each event, events exceeding size are sent in chunks of their own. Zero size returns a single chunk.
+func chunk(events []esdb.EventData, size int) [][]esdb.EventData {
+	if size <= 0 {
+		return [][]esdb.EventData{events}
+	}
+
+	var (
+		chunks [][]esdb.EventData
+		start  int
+		total  int
+	)
+	for i, e := range events {
+		n := len(e.EventType) + len(e.Data) + len(e.Metadata) + eventOverhead
+		if i > start && total+n > size {
+			chunks = append(chunks, events[start:i])
+			start, total = i, 0
+		}
+		total += n
+	}
+	return append(chunks, events[start:])
+}
+
+// duplicate reports whether batch is already stored in stream right after expected revision,
+// i.e. whether append is a retry of a successful one. Revision of the last event is returned.
+// Events without IDs are never considered duplicates.
+func (c *Client) duplicate(ctx context.Context, stream string, expected esdb.ExpectedRevision, batch []esdb.EventData) (uint64, bool, error) {
+	var from uint64
+	switch r := expected.(type) {
+	case esdb.NoStream:
+		from = 0
+	case esdb.StreamRevision:
+		from = r.Value + 1
+	default:
+		return 0, false, nil // stream position of events is unknown
+	}
+
+	s, err := c.ReadStream(ctx, stream, esdb.ReadStreamOptions{
+		From: esdb.StreamRevision{Value: from},
+	}, uint64(len(batch)))
+	if errors.Is(err, esdb.ErrStreamNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, streamError(err)
+	}
+	defer s.Close()
+
+	var revision uint64
+	for _, e := range batch {
+		stored, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, streamError(err)
+		}
+		if uuid.UUID(e.EventID) == uuid.Nil || stored.Event == nil || stored.Event.EventID != e.EventID {
+			return 0, false, nil
+		}
+		revision = stored.Event.EventNumber
+	}
+
+	return revision, true, nil
+}
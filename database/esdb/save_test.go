@@ -0,0 +1,97 @@
+package esdb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/deividaspetraitis/go/trace"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// TestChunk verifies events are split into size bounded chunks preserving order.
+func TestChunk(t *testing.T) {
+	event := func(size int) esdb.EventData {
+		return esdb.EventData{Data: make([]byte, size)}
+	}
+	sizes := func(chunks [][]esdb.EventData) [][]int {
+		var result [][]int
+		for _, c := range chunks {
+			var s []int
+			for _, e := range c {
+				s = append(s, len(e.Data))
+			}
+			result = append(result, s)
+		}
+		return result
+	}
+
+	var testcases = []struct {
+		events []esdb.EventData
+		size   int
+		chunks [][]int
+	}{
+		{events: []esdb.EventData{event(1), event(2), event(3)}, size: 0, chunks: [][]int{{1, 2, 3}}},
+		{events: []esdb.EventData{event(1), event(2), event(3)}, size: 6 + 3*eventOverhead, chunks: [][]int{{1, 2, 3}}},
+		{events: []esdb.EventData{event(1), event(2), event(3)}, size: 6, chunks: [][]int{{1}, {2}, {3}}},
+		{events: []esdb.EventData{event(1), event(2), event(3)}, size: 3 + 2*eventOverhead, chunks: [][]int{{1, 2}, {3}}},
+		{events: []esdb.EventData{event(5), event(1), event(1)}, size: 3 + 2*eventOverhead, chunks: [][]int{{5}, {1, 1}}},
+		{events: []esdb.EventData{event(1), event(5), event(1)}, size: 3 + 2*eventOverhead, chunks: [][]int{{1}, {5}, {1}}},
+	}
+
+	for i, tt := range testcases {
+		if got, want := sizes(chunk(tt.events, tt.size)), tt.chunks; !reflect.DeepEqual(got, want) {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestWithExpectedRevision verifies expected revision options.
+func TestWithExpectedRevision(t *testing.T) {
+	var testcases = []struct {
+		opts     []SaveOption
+		expected esdb.ExpectedRevision
+	}{
+		{opts: nil, expected: nil},
+		{opts: []SaveOption{WithExpectedRevision(AnyRevision)}, expected: esdb.Any{}},
+		{opts: []SaveOption{WithExpectedRevision(NoStream)}, expected: esdb.NoStream{}},
+		{opts: []SaveOption{WithExpectedRevision(StreamExists)}, expected: esdb.StreamExists{}},
+		{opts: []SaveOption{WithExpectedRevision(Revision(7))}, expected: esdb.StreamRevision{Value: 7}},
+	}
+
+	for i, tt := range testcases {
+		if got, want := newSave(tt.opts...).expected, tt.expected; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestEventMetadata verifies trace context is injected into JSON event metadata only.
+func TestEventMetadata(t *testing.T) {
+	ctx, span := trace.Start(context.Background(), "save")
+	defer span.Finish()
+
+	var testcases = []struct {
+		contentType esdb.ContentType
+		md          string
+		traced      bool
+	}{
+		{contentType: ContentTypeJSON, md: "", traced: true},
+		{contentType: ContentTypeJSON, md: `{"user":"test"}`, traced: true},
+		{contentType: ContentTypeBinary, md: "", traced: false},
+		{contentType: ContentTypeBinary, md: `{"user":"test"}`, traced: true},
+		{contentType: ContentTypeBinary, md: "\x0a\x04test", traced: false},
+	}
+
+	for i, tt := range testcases {
+		c := &Client{contentType: tt.contentType}
+		got := c.eventMetadata(ctx, []byte(tt.md))
+		if traced := trace.SpanContextFromContext(trace.ExtractMetadata(context.Background(), got)).IsValid(); traced != tt.traced {
+			t.Errorf("#%d got traced %v, want %v", i, traced, tt.traced)
+		}
+		if !tt.traced && string(got) != tt.md {
+			t.Errorf("#%d got %q, want %q", i, got, tt.md)
+		}
+	}
+}
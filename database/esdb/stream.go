@@ -1,7 +1,65 @@
 package esdb
 
-var streamSeparator = "_"
+import (
+	"strings"
 
-func stream(events []*Event) string {
-	return events[0].Aggregate + "_" + events[0].AggregateID // TODO
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// ErrInvalidStream is returned when stream name can not be parsed into aggregate and id.
+var ErrInvalidStream = errors.New("invalid stream name")
+
+// StreamNamer maps aggregates to EventStore stream names and back.
+type StreamNamer interface {
+	// Stream returns name of the stream holding events of aggregate with given id.
+	Stream(aggregate, id string) string
+
+	// Parse returns aggregate and id of the stream, ErrInvalidStream is returned
+	// for streams not holding events of an aggregate.
+	Parse(stream string) (aggregate, id string, err error)
+}
+
+var (
+	// DefaultStreamNamer names streams as aggregate_id.
+	DefaultStreamNamer = SeparatorStreamNamer("_")
+
+	// CategoryStreamNamer names streams as aggregate-id, following EventStore
+	// category convention required by ReadCategory.
+	CategoryStreamNamer = SeparatorStreamNamer("-")
+)
+
+// separatorStreamNamer implements StreamNamer joining aggregate and id with a separator.
+type separatorStreamNamer struct {
+	separator string
+}
+
+// SeparatorStreamNamer returns StreamNamer joining aggregate and id with separator.
+// Streams are split on the first separator, thus any id round-trips as long as
+// aggregate names do not contain separator.
+func SeparatorStreamNamer(separator string) StreamNamer {
+	return &separatorStreamNamer{separator: separator}
+}
+
+// Stream implements StreamNamer.
+func (n *separatorStreamNamer) Stream(aggregate, id string) string {
+	return aggregate + n.separator + id
+}
+
+// Parse implements StreamNamer.
+func (n *separatorStreamNamer) Parse(stream string) (string, string, error) {
+	if strings.HasPrefix(stream, "$") {
+		return "", "", errors.Wrapf(ErrInvalidStream, "system stream %q", stream)
+	}
+
+	aggregate, id, ok := strings.Cut(stream, n.separator)
+	if !ok || aggregate == "" || id == "" {
+		return "", "", errors.Wrapf(ErrInvalidStream, "stream %q", stream)
+	}
+
+	return aggregate, id, nil
+}
+
+// stream returns name of the stream events belong to.
+func (c *Client) stream(events []*Event) string {
+	return c.namer.Stream(events[0].Aggregate, events[0].AggregateID)
 }
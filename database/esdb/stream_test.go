@@ -0,0 +1,59 @@
+package esdb
+
+import (
+	"testing"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// TestStreamNamer verifies stream names round-trip through namers.
+func TestStreamNamer(t *testing.T) {
+	var testcases = []struct {
+		namer     StreamNamer
+		aggregate string
+		id        string
+
+		stream string
+	}{
+		{namer: DefaultStreamNamer, aggregate: "Order", id: "123", stream: "Order_123"},
+		{namer: DefaultStreamNamer, aggregate: "Order", id: "a_b_c", stream: "Order_a_b_c"},
+		{namer: CategoryStreamNamer, aggregate: "Order", id: "123", stream: "Order-123"},
+		{namer: CategoryStreamNamer, aggregate: "Order", id: "8c9d-4e5f", stream: "Order-8c9d-4e5f"},
+		{namer: SeparatorStreamNamer("::"), aggregate: "Order", id: "1:2", stream: "Order::1:2"},
+	}
+
+	for i, tt := range testcases {
+		stream := tt.namer.Stream(tt.aggregate, tt.id)
+		if got, want := stream, tt.stream; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+
+		aggregate, id, err := tt.namer.Parse(stream)
+		if err != nil {
+			t.Errorf("#%d got %v, want %v", i, err, nil)
+		}
+		if aggregate != tt.aggregate || id != tt.id {
+			t.Errorf("#%d got %v/%v, want %v/%v", i, aggregate, id, tt.aggregate, tt.id)
+		}
+	}
+}
+
+// TestStreamNamerParse verifies streams not holding aggregate events are rejected.
+func TestStreamNamerParse(t *testing.T) {
+	var testcases = []struct {
+		stream string
+		err    error
+	}{
+		{stream: "Order_1", err: nil},
+		{stream: "Order", err: ErrInvalidStream},
+		{stream: "_1", err: ErrInvalidStream},
+		{stream: "Order_", err: ErrInvalidStream},
+		{stream: "$ce_Order", err: ErrInvalidStream},
+	}
+
+	for i, tt := range testcases {
+		if _, _, err := DefaultStreamNamer.Parse(tt.stream); !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package esdb
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/es"
+	"github.com/deividaspetraitis/go/log"
+
+	"github.com/EventStore/EventStore-Client-Go/esdb"
+)
+
+// Subscription represents a live subscription to a stream of events.
+type Subscription struct {
+	sub       *esdb.Subscription
+	event     *Event
+	err       error
+	aggregate string      // aggregate name used for metrics
+	namer     StreamNamer // parses stream names of delivered events
+}
+
+// Subscribe subscribes to the stream of events for specific id.
+// If after is nil only new events are delivered, otherwise events following version after.
+func (c *Client) Subscribe(ctx context.Context, id string, aggregate string, after *Version) (*Subscription, error) {
+	var from esdb.StreamPosition = esdb.End{}
+	if after != nil {
+		from = esdb.StreamRevision{Value: uint64(*after)}
+	}
+
+	sub, err := c.SubscribeToStream(ctx, c.namer.Stream(aggregate, id), esdb.SubscribeToStreamOptions{
+		From: from,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Subscription{sub: sub, aggregate: aggregate, namer: c.namer}, nil
+}
+
+// Next blocks until the next event appears, it returns false once subscription is dropped.
+func (s *Subscription) Next() bool {
+	for {
+		e := s.sub.Recv()
+		switch {
+		case e.SubscriptionDropped != nil:
+			s.err = streamError(e.SubscriptionDropped.Error)
+			return false
+		case e.EventAppeared != nil:
+			event, err := newEvent(s.namer, e.EventAppeared.Event)
+			if err != nil {
+				s.err = err
+				return false
+			}
+			s.event = event
+			eventsTotal.Inc("subscribe", s.aggregate)
+			return true
+		}
+		// checkpoints are irrelevant to stream subscriptions
+	}
+}
+
+// Value returns the event subscription stepped to.
+func (s *Subscription) Value() *Event {
+	return s.event
+}
+
+// Error returns error subscription was dropped with.
+func (s *Subscription) Error() error {
+	return s.err
+}
+
+// Close closes the subscription.
+func (s *Subscription) Close() error {
+	return s.sub.Close()
+}
+
+// newNotification constructs es.Notification about e committed at position.
+func newNotification(e *Event, position string) es.Notification {
+	var data any = e.Data // non JSON payloads are encoded as base64
+	if json.Valid(e.Data) {
+		data = json.RawMessage(e.Data)
+	}
+	return es.Notification{
+		Position:    position,
+		AggregateID: e.AggregateID,
+		Aggregate:   e.Aggregate,
+		Version:     es.Version(e.Version),
+		Type:        e.Type,
+		Timestamp:   e.Timestamp,
+		Data:        data,
+	}
+}
+
+// StreamNotifier returns es.Notifier notifying about events of aggregate stream for specific id.
+// Event versions are used as notification positions.
+func (c *Client) StreamNotifier(id string, aggregate string) es.Notifier {
+	return es.NotifierFunc(func(ctx context.Context, after string) (<-chan es.Notification, error) {
+		var version *Version
+		if after != "" {
+			v, err := strconv.ParseUint(after, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(es.ErrInvalidPosition, "position %q", after)
+			}
+			version = (*Version)(&v)
+		}
+
+		sub, err := c.Subscribe(ctx, id, aggregate, version)
+		if err != nil {
+			return nil, err
+		}
+
+		ch := make(chan es.Notification)
+		go func() {
+			defer close(ch)
+			defer sub.Close()
+
+			for sub.Next() {
+				e := sub.Value()
+				select {
+				case ch <- newNotification(e, strconv.FormatUint(uint64(e.Version), 10)):
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err := sub.Error(); err != nil && ctx.Err() == nil {
+				log.WithError(err).WithFields(log.Fields{"aggregate": aggregate, "id": id}).Error("esdb: subscription dropped")
+			}
+		}()
+
+		return ch, nil
+	})
+}
+
+// Notify implements es.Notifier notifying about events of all aggregates committed after position,
+// or only about new events if position is empty. Positions in the $all stream are used as notification
+// positions. System events and events of streams not holding aggregates are skipped.
+func (c *Client) Notify(ctx context.Context, after string) (<-chan es.Notification, error) {
+	var from esdb.AllPosition = esdb.End{}
+	if after != "" {
+		p, err := ParsePosition(after)
+		if err != nil {
+			return nil, errors.Wrapf(es.ErrInvalidPosition, "position %q", after)
+		}
+		from = esdb.Position{Commit: p.Commit, Prepare: p.Prepare}
+	}
+
+	sub, err := c.SubscribeToAll(ctx, esdb.SubscribeToAllOptions{
+		From:   from,
+		Filter: esdb.ExcludeSystemEventsFilter(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan es.Notification)
+	go func() {
+		defer close(ch)
+		defer sub.Close()
+
+		for {
+			e := sub.Recv()
+			switch {
+			case e.SubscriptionDropped != nil:
+				if ctx.Err() == nil {
+					log.WithError(streamError(e.SubscriptionDropped.Error)).Error("esdb: subscription to $all dropped")
+				}
+				return
+			case e.EventAppeared != nil:
+				event, err := newEvent(c.namer, e.EventAppeared.Event)
+				if err != nil {
+					continue // not an aggregate stream
+				}
+
+				select {
+				case ch <- newNotification(event, event.Position.String()):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
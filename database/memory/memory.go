@@ -0,0 +1,212 @@
+// package memory implements in-memory event store suitable for tests and local development.
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/es"
+
+	"github.com/google/uuid"
+)
+
+// Record is an event along with its position in the store log.
+type Record struct {
+	Position uint64 // position of the event in the store log, starting at 1
+	Event    *es.Event
+}
+
+// Store is an in-memory event store.
+type Store struct {
+	mu      sync.RWMutex // guard fields below
+	streams map[string][]*es.Event
+	log     []*es.Event   // all events in commit order
+	changed chan struct{} // closed and replaced once new events are committed
+}
+
+// NewStore constructs a new empty Store.
+func NewStore() *Store {
+	return &Store{
+		streams: make(map[string][]*es.Event),
+		changed: make(chan struct{}),
+	}
+}
+
+// stream returns name of the stream event belongs to.
+func stream(aggregate, id string) string {
+	return aggregate + "_" + id
+}
+
+// Save atomically appends events of a single aggregate to its stream.
+// Events must continue stream version sequence, otherwise es.ErrVersionMismatch is returned.
+// Events already stored under the same ID at the same version are skipped, thus a save may be safely retried.
+func (s *Store) Save(ctx context.Context, events []*es.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	name := stream(es.ParseAggregateName(events[0].Aggregate), events[0].AggregateID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.streams[name]
+	version := es.Version(len(stored))
+
+	var pending []*es.Event
+	for i, e := range events {
+		if stream(es.ParseAggregateName(e.Aggregate), e.AggregateID) != name {
+			return errors.New("events belong to different streams")
+		}
+		if i > 0 && e.Version != events[i-1].Version+1 {
+			return errors.Wrapf(es.ErrVersionMismatch, "events are not sequential")
+		}
+		if len(pending) == 0 && duplicate(stored, e) {
+			continue
+		}
+		if e.Version != version+1 {
+			return errors.Wrapf(es.ErrVersionMismatch, "stream %s expected version %d, got %d", name, version+1, e.Version)
+		}
+		version++
+		pending = append(pending, e)
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	s.streams[name] = append(stored, pending...)
+	s.log = append(s.log, pending...)
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+
+	return nil
+}
+
+// duplicate reports whether e is already stored in stream at its version.
+func duplicate(stream []*es.Event, e *es.Event) bool {
+	if e.ID == uuid.Nil || e.Version == 0 || int(e.Version) > len(stream) {
+		return false
+	}
+	return stream[e.Version-1].ID == e.ID
+}
+
+// SaveAggregate saves pending events of aggregate and marks them as persisted.
+// It is compatible with database.SaveAggregateFunc.
+func (s *Store) SaveAggregate(ctx context.Context, aggregate es.Aggregate) error {
+	events := aggregate.Events()
+	if err := s.Save(ctx, events); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := aggregate.Sync(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Events returns events of aggregate stream for specific id following version after.
+func (s *Store) Events(ctx context.Context, aggregate string, id string, after es.Version) ([]*es.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := s.streams[stream(aggregate, id)]
+	if uint64(after) >= uint64(len(events)) {
+		return nil, nil
+	}
+	return append([]*es.Event(nil), events[after:]...), nil
+}
+
+// Position returns position of the most recently committed event.
+func (s *Store) Position() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return uint64(len(s.log))
+}
+
+// Subscribe returns channel delivering all events following position after, including events committed later on.
+// Channel is closed once ctx is done.
+func (s *Store) Subscribe(ctx context.Context, after uint64) <-chan Record {
+	ch := make(chan Record)
+	go func() {
+		defer close(ch)
+
+		position := after
+		for {
+			s.mu.RLock()
+			var events []*es.Event
+			if position < uint64(len(s.log)) {
+				events = s.log[position:]
+			}
+			changed := s.changed
+			s.mu.RUnlock()
+
+			for _, e := range events {
+				position++
+				select {
+				case ch <- Record{Position: position, Event: e}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if len(events) == 0 {
+				select {
+				case <-changed:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// newNotification constructs es.Notification about e committed at store log position.
+func newNotification(e *es.Event, position uint64) es.Notification {
+	return es.Notification{
+		Position:    strconv.FormatUint(position, 10),
+		AggregateID: e.AggregateID,
+		Aggregate:   es.ParseAggregateName(e.Aggregate),
+		Version:     e.Version,
+		Type:        e.Type,
+		Timestamp:   e.Timestamp,
+		Data:        e.Data,
+	}
+}
+
+// Notify implements es.Notifier notifying about all committed events.
+// Store log positions are used as notification positions, positions beyond
+// the current one result in es.ErrInvalidPosition.
+func (s *Store) Notify(ctx context.Context, after string) (<-chan es.Notification, error) {
+	position := s.Position()
+	if after != "" {
+		v, err := strconv.ParseUint(after, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(es.ErrInvalidPosition, "position %q", after)
+		}
+		if v > position {
+			return nil, errors.Wrapf(es.ErrInvalidPosition, "position %q beyond %d", after, position)
+		}
+		position = v
+	}
+
+	ch := make(chan es.Notification)
+	go func() {
+		defer close(ch)
+
+		for rec := range s.Subscribe(ctx, position) {
+			select {
+			case ch <- newNotification(rec.Event, rec.Position):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/es"
+
+	"github.com/google/uuid"
+)
+
+type order struct {
+	es.AggregateRoot
+}
+
+type orderPlaced struct {
+	Amount int `json:"amount"`
+}
+
+func (e *orderPlaced) MarshalJSON() ([]byte, error) {
+	type alias orderPlaced
+	return json.Marshal((*alias)(e))
+}
+
+func (e *orderPlaced) UnmarshalJSON(b []byte) error {
+	type alias orderPlaced
+	return json.Unmarshal(b, (*alias)(e))
+}
+
+// TestStore verifies events are appended in version order and delivered to subscribers.
+func TestStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	store := NewStore()
+	records := store.Subscribe(ctx, 0)
+
+	agg := &order{}
+	for i := 0; i < 2; i++ {
+		if err := agg.Apply(es.NewEvent("1", agg, &orderPlaced{Amount: i})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SaveAggregate(ctx, agg); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(agg.Events()), 0; got != want {
+		t.Errorf("got %v pending events, want %v", got, want)
+	}
+
+	stale := &es.Event{AggregateID: "1", Aggregate: agg, Version: 2}
+	if err := store.Save(ctx, []*es.Event{stale}); !errors.Is(err, es.ErrVersionMismatch) {
+		t.Errorf("got %v, want %v", err, es.ErrVersionMismatch)
+	}
+
+	events, err := store.Events(ctx, "order", "1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(events), 1; got != want {
+		t.Fatalf("got %v events, want %v", got, want)
+	}
+	if got, want := events[0].Version, es.Version(2); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for i := uint64(1); i <= 2; i++ {
+		select {
+		case rec := <-records:
+			if got, want := rec.Position, i; got != want {
+				t.Errorf("#%d got %v, want %v", i, got, want)
+			}
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+}
+
+// TestStoreIdempotentSave verifies retried saves of already stored events succeed without duplicating them.
+func TestStoreIdempotentSave(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	agg := &order{}
+	var events []*es.Event
+	for i := 0; i < 3; i++ {
+		events = append(events, es.NewEvent("1", agg, &orderPlaced{Amount: i}))
+	}
+	conflicting := &es.Event{ID: uuid.New(), AggregateID: "1", Aggregate: agg, Version: 2}
+
+	var testcases = []struct {
+		events   []*es.Event
+		err      error
+		position uint64
+	}{
+		{events: events[:2], err: nil, position: 2},
+		{events: events[:2], err: nil, position: 2},
+		{events: events[1:], err: nil, position: 3},
+		{events: events, err: nil, position: 3},
+		{events: []*es.Event{conflicting}, err: es.ErrVersionMismatch, position: 3},
+	}
+
+	for i, tt := range testcases {
+		if err := store.Save(ctx, tt.events); !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if got, want := store.Position(), tt.position; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestStoreNotify verifies notifications are resumed after given position.
+func TestStoreNotify(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	store := NewStore()
+	agg := &order{}
+	for i := 0; i < 3; i++ {
+		if err := agg.Apply(es.NewEvent("1", agg, &orderPlaced{Amount: i})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SaveAggregate(ctx, agg); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, after := range []string{"x", "4"} {
+		if _, err := store.Notify(ctx, after); !errors.Is(err, es.ErrInvalidPosition) {
+			t.Errorf("got %v, want %v", err, es.ErrInvalidPosition)
+		}
+	}
+
+	notifications, err := store.Notify(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"2", "3"} {
+		select {
+		case n := <-notifications:
+			if n.Position != want || n.Aggregate != "order" || n.AggregateID != "1" {
+				t.Errorf("got %+v, want position %v", n, want)
+			}
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/deividaspetraitis/go/es"
+	"github.com/deividaspetraitis/go/metrics"
+)
+
+var (
+	loadDuration = metrics.NewHistogram(
+		"aggregate_load_duration_seconds",
+		"Duration of loading aggregate from persistent storage.",
+		metrics.DefBuckets,
+		"aggregate", "status",
+	)
+	saveDuration = metrics.NewHistogram(
+		"aggregate_save_duration_seconds",
+		"Duration of storing aggregate into persistent storage.",
+		metrics.DefBuckets,
+		"aggregate", "status",
+	)
+)
+
+func init() {
+	metrics.Register(loadDuration, saveDuration)
+}
+
+// InstrumentGetAggregate wraps fn recording aggregate load durations.
+func InstrumentGetAggregate[T any](fn GetAggregateFunc[T]) GetAggregateFunc[T] {
+	return func(ctx context.Context, aggregate es.Aggregate, id string) (T, error) {
+		start := time.Now()
+		v, err := fn(ctx, aggregate, id)
+		loadDuration.ObserveSince(start, es.ParseAggregateName(aggregate), metrics.Status(err))
+		return v, err
+	}
+}
+
+// InstrumentSaveAggregate wraps fn recording aggregate save durations.
+func InstrumentSaveAggregate(fn SaveAggregateFunc) SaveAggregateFunc {
+	return func(ctx context.Context, aggregate es.Aggregate) error {
+		start := time.Now()
+		err := fn(ctx, aggregate)
+		saveDuration.ObserveSince(start, es.ParseAggregateName(aggregate), metrics.Status(err))
+		return err
+	}
+}
@@ -0,0 +1,169 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/idempotency"
+	"github.com/deividaspetraitis/go/log"
+)
+
+// DefaultIdempotencyTable is the default name of idempotency records table,
+// it is created by migrations shipped in database/migrations.
+const DefaultIdempotencyTable = "idempotency_keys"
+
+// defaultSweepInterval is the default interval of deleting expired records.
+const defaultSweepInterval = time.Minute
+
+// IdempotencyStore implements idempotency.Store persisting records in Postgres table.
+// Expired records are deleted by Begin at most once per minute.
+type IdempotencyStore struct {
+	db    *DB
+	table string
+
+	mu    sync.Mutex // guard fields below
+	swept time.Time
+}
+
+// NewIdempotencyStore constructs and returns new IdempotencyStore instance
+// storing records in table, if table is empty DefaultIdempotencyTable is used.
+// Other tables must be created by migrations of the application following
+// database/migrations/000001_create_idempotency_keys.up.sql.
+func NewIdempotencyStore(db *DB, table string) *IdempotencyStore {
+	if table == "" {
+		table = DefaultIdempotencyTable
+	}
+	return &IdempotencyStore{
+		db:    db,
+		table: table,
+	}
+}
+
+// DeleteExpired deletes expired records and returns number of deleted records.
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE expires_at <= $1`, s.table), s.db.Now().UTC())
+	if err != nil {
+		return 0, errors.Wrap(err, "sql: delete expired idempotent requests")
+	}
+	return result.RowsAffected()
+}
+
+// sweep deletes expired records at most once per interval.
+func (s *IdempotencyStore) sweep(ctx context.Context, now time.Time, interval time.Duration) {
+	s.mu.Lock()
+	if now.Sub(s.swept) < interval {
+		s.mu.Unlock()
+		return
+	}
+	s.swept = now
+	s.mu.Unlock()
+
+	if _, err := s.DeleteExpired(ctx); err != nil {
+		log.WithError(err).WithFields(log.Fields{"table": s.table}).Error("sql: sweeping idempotency records")
+	}
+}
+
+// Begin implements idempotency.Store.
+func (s *IdempotencyStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*idempotency.Record, bool, error) {
+	now := s.db.Now().UTC()
+	s.sweep(ctx, now, defaultSweepInterval)
+
+	// insert a new record or take over an expired one
+	var inserted string
+	err := s.db.db.QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO %[1]s (key, fingerprint, created_at, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status = NULL,
+			header = NULL,
+			body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE %[1]s.expires_at <= EXCLUDED.created_at
+		RETURNING key`, s.table), key, fingerprint, now, now.Add(ttl)).Scan(&inserted)
+	switch {
+	case err == nil:
+		return &idempotency.Record{
+			Key:         key,
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}, true, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, false, errors.Wrap(err, "sql: begin idempotent request")
+	}
+
+	rec, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	return rec, false, nil
+}
+
+// Complete implements idempotency.Store.
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, res *idempotency.Response, ttl time.Duration) error {
+	header, err := json.Marshal(res.Header)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.db.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s SET status = $2, header = $3, body = $4, expires_at = $5
+		WHERE key = $1`, s.table), key, res.Status, header, res.Body, s.db.Now().UTC().Add(ttl))
+	if err != nil {
+		return errors.Wrap(err, "sql: complete idempotent request")
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return idempotency.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Release implements idempotency.Store.
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE key = $1 AND status IS NULL`, s.table), key)
+	if err != nil {
+		return errors.Wrap(err, "sql: release idempotent request")
+	}
+	return nil
+}
+
+// Get implements idempotency.Store.
+func (s *IdempotencyStore) Get(ctx context.Context, key string) (*idempotency.Record, error) {
+	var (
+		rec    idempotency.Record
+		status sql.NullInt64
+		header []byte
+		body   []byte
+	)
+
+	err := s.db.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT key, fingerprint, status, header, body, created_at, expires_at
+		FROM %s WHERE key = $1 AND expires_at > $2`, s.table), key, s.db.Now().UTC()).
+		Scan(&rec.Key, &rec.Fingerprint, &status, &header, &body, &rec.CreatedAt, &rec.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, idempotency.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: get idempotent request")
+	}
+
+	if status.Valid {
+		rec.Response = &idempotency.Response{
+			Status: int(status.Int64),
+			Body:   body,
+		}
+		if err := json.Unmarshal(header, &rec.Response.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	return &rec, nil
+}
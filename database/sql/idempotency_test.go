@@ -0,0 +1,117 @@
+package sql
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/database"
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/idempotency"
+
+	"github.com/google/uuid"
+)
+
+// openTestDB opens database at POSTGRES_DSN, the test is skipped if it is not set.
+func openTestDB(t *testing.T) *DB {
+	dsn := os.Getenv("POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_DSN is not set")
+	}
+
+	db := NewDB(context.Background(), &database.Config{ConnectionString: dsn})
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestIdempotencyStore verifies concurrent requests with the same key conflict until released or expired.
+func TestIdempotencyStore(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	db.Now = func() time.Time { return now }
+
+	ctx := context.Background()
+	s := NewIdempotencyStore(db, "")
+	key := uuid.NewString()
+
+	if _, created, err := s.Begin(ctx, key, "a", time.Minute); err != nil || !created {
+		t.Fatalf("got %v %v, want %v %v", created, err, true, nil)
+	}
+
+	// in flight request conflicts
+	rec, created, err := s.Begin(ctx, key, "b", time.Minute)
+	if err != nil || created {
+		t.Fatalf("got %v %v, want %v %v", created, err, false, nil)
+	}
+	if got, want := rec.Fingerprint, "a"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// released request is begun again
+	if err := s.Release(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, created, err := s.Begin(ctx, key, "b", time.Minute); err != nil || !created {
+		t.Fatalf("got %v %v, want %v %v", created, err, true, nil)
+	}
+
+	// completed request is replayed and not released
+	res := &idempotency.Response{Status: http.StatusCreated, Header: http.Header{"Location": {"/orders/1"}}, Body: []byte("{}")}
+	if err := s.Complete(ctx, key, res, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Release(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	rec, created, err = s.Begin(ctx, key, "b", time.Minute)
+	if err != nil || created {
+		t.Fatalf("got %v %v, want %v %v", created, err, false, nil)
+	}
+	if rec.Response == nil || rec.Response.Status != res.Status || rec.Response.Header.Get("Location") != "/orders/1" {
+		t.Errorf("got %+v, want %+v", rec.Response, res)
+	}
+
+	// expired record is taken over
+	now = now.Add(2 * time.Hour)
+	if _, created, err := s.Begin(ctx, key, "c", time.Minute); err != nil || !created {
+		t.Fatalf("got %v %v, want %v %v", created, err, true, nil)
+	}
+
+	if err := s.Complete(ctx, uuid.NewString(), res, time.Hour); !errors.Is(err, idempotency.ErrRecordNotFound) {
+		t.Errorf("got %v, want %v", err, idempotency.ErrRecordNotFound)
+	}
+}
+
+// TestIdempotencyStoreDeleteExpired verifies expired records are deleted.
+func TestIdempotencyStoreDeleteExpired(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now().UTC()
+	db.Now = func() time.Time { return now }
+
+	ctx := context.Background()
+	s := NewIdempotencyStore(db, "")
+	key := uuid.NewString()
+
+	if _, _, err := s.Begin(ctx, key, "a", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(time.Hour)
+	n, err := s.DeleteExpired(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n < 1 {
+		t.Errorf("got %v, want at least %v", n, 1)
+	}
+
+	now = now.Add(-time.Hour)
+	if _, err := s.Get(ctx, key); !errors.Is(err, idempotency.ErrRecordNotFound) {
+		t.Errorf("got %v, want %v", err, idempotency.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,111 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/pagination"
+)
+
+// KeysetColumn describes column result set is sorted by.
+type KeysetColumn struct {
+	Name string // Column name, it is not escaped
+	Desc bool   // Whether column is sorted in descending order
+}
+
+// Keyset represents keyset pagination clauses of a page.
+type Keyset struct {
+	Where   string // Condition selecting rows after cursor, empty for the first page
+	OrderBy string // Sort order
+	Limit   int    // Number of rows to fetch, one larger than page limit
+	Args    []any  // Arguments referenced by Where
+}
+
+// KeysetSort returns sort order of columns, pages should carry it as pagination.Page.Sort
+// so that cursors are bound to the order they were produced in.
+func KeysetSort(columns ...KeysetColumn) string {
+	order := make([]string, len(columns))
+	for i, c := range columns {
+		order[i] = c.Name + " ASC"
+		if c.Desc {
+			order[i] = c.Name + " DESC"
+		}
+	}
+	return strings.Join(order, ", ")
+}
+
+// NewKeyset builds keyset pagination clauses for page sorted by columns.
+// Placeholders of Where start at $argOffset+1. Columns should identify rows uniquely,
+// ie. end with primary key, otherwise rows sharing sort keys might be skipped.
+// Cursor produced for different sort order is rejected with pagination.ErrInvalidCursor.
+func NewKeyset(page pagination.Page, argOffset int, columns ...KeysetColumn) (*Keyset, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("sql: keyset requires at least one column")
+	}
+
+	k := &Keyset{
+		OrderBy: KeysetSort(columns...),
+		Limit:   page.Limit + 1,
+	}
+
+	if page.After == nil {
+		return k, nil
+	}
+
+	if page.After.Sort != k.OrderBy || len(page.After.Keys) != len(columns) {
+		return nil, pagination.ErrInvalidCursor
+	}
+
+	// (a > $1) OR (a = $1 AND b > $2) OR ...
+	for _, v := range page.After.Keys {
+		if n, ok := v.(json.Number); ok {
+			v = n.String()
+		}
+		k.Args = append(k.Args, v)
+	}
+
+	var or []string
+	for i, c := range columns {
+		var and []string
+		for j := 0; j < i; j++ {
+			and = append(and, fmt.Sprintf("%s = $%d", columns[j].Name, argOffset+j+1))
+		}
+		op := ">"
+		if c.Desc {
+			op = "<"
+		}
+		and = append(and, fmt.Sprintf("%s %s $%d", c.Name, op, argOffset+i+1))
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	k.Where = "(" + strings.Join(or, " OR ") + ")"
+
+	return k, nil
+}
+
+// Apply wraps query into a subquery paginated by keyset.
+// Column names must refer to columns of query result.
+func (k *Keyset) Apply(query string, args ...any) (string, []any) {
+	q := "SELECT * FROM (" + query + ") AS page"
+	if k.Where != "" {
+		q += " WHERE " + k.Where
+	}
+	q += fmt.Sprintf(" ORDER BY %s LIMIT %d", k.OrderBy, k.Limit)
+
+	return q, append(append([]any(nil), args...), k.Args...)
+}
+
+// QueryPage executes query within transaction returning rows of page sorted by columns.
+// It fetches one more row than page limit to signal existence of the next page,
+// see pagination.NewResult. Page sort order should be set to KeysetSort(columns...).
+func (tx *Tx) QueryPage(ctx context.Context, page pagination.Page, columns []KeysetColumn, query string, args ...any) (*sql.Rows, error) {
+	k, err := NewKeyset(page, len(args), columns...)
+	if err != nil {
+		return nil, err
+	}
+	q, a := k.Apply(query, args...)
+	return tx.QueryContext(ctx, q, a...)
+}
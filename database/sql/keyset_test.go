@@ -0,0 +1,136 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/pagination"
+)
+
+// TestKeyset verifies generated keyset pagination query and its arguments.
+func TestKeyset(t *testing.T) {
+	id := []KeysetColumn{{Name: "id"}}
+	mixed := []KeysetColumn{{Name: "created_at", Desc: true}, {Name: "id"}}
+
+	var testcases = []struct {
+		page      pagination.Page
+		argOffset int
+		columns   []KeysetColumn
+		args      []any
+
+		query     string
+		queryArgs []any
+		err       error
+	}{
+		{
+			page:    pagination.Page{Limit: 10},
+			columns: id,
+			query:   "SELECT * FROM (SELECT * FROM items) AS page ORDER BY id ASC LIMIT 11",
+		},
+		{
+			page:      pagination.Page{Limit: 10, After: &pagination.Cursor{Sort: "id ASC", Keys: []any{json.Number("7")}}},
+			columns:   id,
+			query:     "SELECT * FROM (SELECT * FROM items) AS page WHERE ((id > $1)) ORDER BY id ASC LIMIT 11",
+			queryArgs: []any{"7"},
+		},
+		{
+			page:      pagination.Page{Limit: 5},
+			argOffset: 1,
+			columns:   mixed,
+			args:      []any{"owner"},
+			query:     "SELECT * FROM (SELECT * FROM items WHERE owner = $1) AS page ORDER BY created_at DESC, id ASC LIMIT 6",
+			queryArgs: []any{"owner"},
+		},
+		{
+			page:      pagination.Page{Limit: 5, After: &pagination.Cursor{Sort: "created_at DESC, id ASC", Keys: []any{"2024-01-01T00:00:00Z", json.Number("7")}}},
+			argOffset: 1,
+			columns:   mixed,
+			args:      []any{"owner"},
+			query:     "SELECT * FROM (SELECT * FROM items WHERE owner = $1) AS page WHERE ((created_at < $2) OR (created_at = $2 AND id > $3)) ORDER BY created_at DESC, id ASC LIMIT 6",
+			queryArgs: []any{"owner", "2024-01-01T00:00:00Z", "7"},
+		},
+		{
+			page:    pagination.Page{Limit: 5, After: &pagination.Cursor{Sort: "created_at ASC, id ASC", Keys: []any{"2024-01-01T00:00:00Z", json.Number("7")}}},
+			columns: mixed,
+			err:     pagination.ErrInvalidCursor,
+		},
+		{
+			page:    pagination.Page{Limit: 5, After: &pagination.Cursor{Sort: "created_at DESC, id ASC", Keys: []any{json.Number("7")}}},
+			columns: mixed,
+			err:     pagination.ErrInvalidCursor,
+		},
+		{
+			page: pagination.Page{Limit: 5},
+			err:  errors.New("sql: keyset requires at least one column"),
+		},
+	}
+
+	for i, tt := range testcases {
+		k, err := NewKeyset(tt.page, tt.argOffset, tt.columns...)
+		if tt.err != nil {
+			if err == nil || err.Error() != tt.err.Error() {
+				t.Errorf("#%d got %v, want %v", i, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+
+		base := "SELECT * FROM items"
+		if len(tt.args) > 0 {
+			base += " WHERE owner = $1"
+		}
+		query, args := k.Apply(base, tt.args...)
+		if query != tt.query {
+			t.Errorf("#%d got %v, want %v", i, query, tt.query)
+		}
+		if !reflect.DeepEqual(args, tt.queryArgs) {
+			t.Errorf("#%d got %v, want %v", i, args, tt.queryArgs)
+		}
+	}
+}
+
+// TestQueryPage verifies pages of mixed sort order continue after cursor.
+func TestQueryPage(t *testing.T) {
+	db := openTestDB(t)
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	columns := []KeysetColumn{{Name: "grp", Desc: true}, {Name: "id"}}
+	page := pagination.Page{
+		Limit: 2,
+		After: &pagination.Cursor{Sort: KeysetSort(columns...), Keys: []any{json.Number("2"), json.Number("2")}},
+	}
+	query := "SELECT * FROM (VALUES (1, 1), (1, 2), (2, 1), (2, 2), (2, 3), (3, 1)) AS items(grp, id) WHERE grp < $1"
+
+	rows, err := tx.QueryPage(ctx, page, columns, query, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got [][2]int
+	for rows.Next() {
+		var row [2]int
+		if err := rows.Scan(&row[0], &row[1]); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, row)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := [][2]int{{2, 3}, {1, 1}, {1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package sql
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/deividaspetraitis/go/metrics"
+)
+
+// pools collects connection pool statistics of all open databases.
+var pools = &poolCollector{dbs: make(map[*DB]struct{})}
+
+func init() {
+	metrics.Register(pools)
+}
+
+// poolCollector implements metrics.Collector reporting sql.DB pool stats.
+type poolCollector struct {
+	mu    sync.Mutex // guard fields below
+	dbs   map[*DB]struct{}
+	count uint64 // number of assigned pool names
+}
+
+// pool returns default pool name of a new DB.
+func (c *poolCollector) pool() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count++
+	return strconv.FormatUint(c.count, 10)
+}
+
+// add starts collecting stats of db.
+func (c *poolCollector) add(db *DB) {
+	c.mu.Lock()
+	c.dbs[db] = struct{}{}
+	c.mu.Unlock()
+}
+
+// remove stops collecting stats of db.
+func (c *poolCollector) remove(db *DB) {
+	c.mu.Lock()
+	delete(c.dbs, db)
+	c.mu.Unlock()
+}
+
+// Collect implements metrics.Collector.
+func (c *poolCollector) Collect() []metrics.Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	families := []metrics.Family{
+		{Name: "sql_pool_max_open_connections", Help: "Maximum number of open connections to the database.", Type: metrics.GaugeType},
+		{Name: "sql_pool_open_connections", Help: "The number of established connections both in use and idle.", Type: metrics.GaugeType},
+		{Name: "sql_pool_in_use_connections", Help: "The number of connections currently in use.", Type: metrics.GaugeType},
+		{Name: "sql_pool_idle_connections", Help: "The number of idle connections.", Type: metrics.GaugeType},
+		{Name: "sql_pool_wait_count_total", Help: "The total number of connections waited for.", Type: metrics.CounterType},
+		{Name: "sql_pool_wait_duration_seconds_total", Help: "The total time blocked waiting for a new connection.", Type: metrics.CounterType},
+		{Name: "sql_pool_max_idle_closed_total", Help: "The total number of connections closed due to SetMaxIdleConns.", Type: metrics.CounterType},
+		{Name: "sql_pool_max_lifetime_closed_total", Help: "The total number of connections closed due to SetConnMaxLifetime.", Type: metrics.CounterType},
+	}
+
+	for db := range c.dbs {
+		if db.db == nil {
+			continue
+		}
+
+		stats := db.db.Stats()
+		labels := []metrics.Label{{Name: "database", Value: db.name}, {Name: "pool", Value: db.Pool}}
+		for i, v := range []float64{
+			float64(stats.MaxOpenConnections),
+			float64(stats.OpenConnections),
+			float64(stats.InUse),
+			float64(stats.Idle),
+			float64(stats.WaitCount),
+			stats.WaitDuration.Seconds(),
+			float64(stats.MaxIdleClosed),
+			float64(stats.MaxLifetimeClosed),
+		} {
+			families[i].Samples = append(families[i].Samples, metrics.Sample{Labels: labels, Value: v})
+		}
+	}
+
+	return families
+}
@@ -0,0 +1,44 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/deividaspetraitis/go/database"
+	"github.com/deividaspetraitis/go/metrics"
+)
+
+// TestPoolCollector verifies pool stats of databases sharing database name are reported separately.
+func TestPoolCollector(t *testing.T) {
+	c := &poolCollector{dbs: make(map[*DB]struct{})}
+
+	var want [][]metrics.Label
+	for _, pool := range []string{"", "replica"} {
+		db := NewDB(context.Background(), &database.Config{Database: "orders"})
+		if pool != "" {
+			db.Pool = pool
+		}
+
+		var err error
+		if db.db, err = sql.Open("postgres", "host=localhost"); err != nil { // does not connect
+			t.Fatal(err)
+		}
+		defer db.db.Close()
+
+		c.add(db)
+		want = append(want, []metrics.Label{{Name: "database", Value: "orders"}, {Name: "pool", Value: db.Pool}})
+	}
+
+	var got [][]metrics.Label
+	for _, s := range c.Collect()[0].Samples {
+		got = append(got, s.Labels)
+	}
+	if len(got) == 2 && got[0][1].Value != want[0][1].Value {
+		got[0], got[1] = got[1], got[0] // collected in map order
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -19,6 +19,7 @@ type DB struct {
 	db     *sql.DB
 	ctx    context.Context // background context
 	cancel func()          // cancel background context
+	name   string          // database name
 
 	// Datasource name
 	DSN string
@@ -26,6 +27,10 @@ type DB struct {
 	// Path to database migrations
 	MigrationSource string
 
+	// Name distinguishing connection pool metrics of databases sharing database name
+	// Defaults to sequence number of the DB
+	Pool string
+
 	// Returns the current time. Defaults to time.Now()
 	// Can be mocked for tests
 	Now func() time.Time
@@ -34,8 +39,10 @@ type DB struct {
 // NewDB returns a new instance of DB associated with the given datasource name.
 func NewDB(ctx context.Context, cfg *database.Config) *DB {
 	db := &DB{
+		name:            cfg.Database,
 		DSN:             cfg.DSN(),
 		MigrationSource: cfg.MigrationsSource,
+		Pool:            pools.pool(),
 		Now:             time.Now,
 	}
 	db.ctx, db.cancel = context.WithCancel(ctx)
@@ -64,6 +71,9 @@ func (db *DB) Open() (err error) {
 		return errors.Wrap(err, "sql: migrate schema")
 	}
 
+	// Collect connection pool stats
+	pools.add(db)
+
 	return nil
 }
 
@@ -95,6 +105,9 @@ func (db *DB) Close() error {
 	// Cancel background context.
 	db.cancel()
 
+	// Stop collecting connection pool stats.
+	pools.remove(db)
+
 	// Close database.
 	if db.db != nil {
 		return db.db.Close()
@@ -102,6 +115,14 @@ func (db *DB) Close() error {
 	return nil
 }
 
+// Check verifies the database connection is alive.
+func (db *DB) Check(ctx context.Context) error {
+	if db.db == nil {
+		return errors.New("sql: database is not open")
+	}
+	return db.db.PingContext(ctx)
+}
+
 func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	tx, err := db.db.BeginTx(ctx, opts)
 	if err != nil {
@@ -1,12 +1,15 @@
 package es
 
 import (
+	"context"
 	"encoding/json"
 	"sync"
 	"time"
 
 	"github.com/deividaspetraitis/go/errors"
 	"github.com/deividaspetraitis/go/slices"
+
+	"github.com/google/uuid"
 )
 
 // registered events for the aggregates
@@ -33,27 +36,49 @@ type Version uint64
 // Sequence of events represents sequence of different states in time
 // used to restore aggregate to its most recent state.
 type Event struct {
+	ID          uuid.UUID          // Unique event ID, stable across retried saves
 	AggregateID string             // Aggregate ID
 	Version     Version            // Event version
 	Type        string             // Name of Data type
 	Aggregate   any                // Aggregrate type
 	Timestamp   time.Time          // Event creation time
 	Data        MarshalUnmarshaler // Actual event data type
-	Metadata    []byte             // Additional data type
+	Metadata    []byte             // Encoded Metadata
 }
 
 // NewEvent constructs and returns new Event based on provided inputs.
+// Event starts a new workflow, use NewEventContext to continue one.
 func NewEvent(id string, agg Aggregate, event MarshalUnmarshaler) *Event {
+	return NewEventContext(context.Background(), id, agg, event)
+}
+
+// NewEventContext constructs and returns new Event carrying Metadata propagated by ctx.
+func NewEventContext(ctx context.Context, id string, agg Aggregate, event MarshalUnmarshaler) *Event {
+	meta := MetadataFromContext(ctx, event)
+	md, _ := json.Marshal(meta) // encoding of Metadata does not fail
 	return &Event{
+		ID:          meta.EventID,
 		AggregateID: id,
 		Version:     agg.Root().AdvanceVersion(), // increase version
 		Aggregate:   agg,
 		Type:        ParseEventName(event),
 		Timestamp:   time.Now().UTC(),
 		Data:        event,
+		Metadata:    md,
 	}
 }
 
+// Meta returns Metadata decoded from event Metadata.
+func (e *Event) Meta() (Metadata, error) {
+	return ParseMetadata(e.Metadata)
+}
+
+// Context returns a copy of ctx continuing the trace and workflow stored in event Metadata,
+// events produced within returned context are caused by e.
+func (e *Event) Context(ctx context.Context) context.Context {
+	return ContextWithEventMetadata(ctx, e.Metadata)
+}
+
 type MarshalUnmarshaler interface {
 	json.Marshaler
 	json.Unmarshaler
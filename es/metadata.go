@@ -0,0 +1,153 @@
+package es
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/deividaspetraitis/go/trace"
+
+	"github.com/google/uuid"
+)
+
+// Metadata represents structured event metadata stored in Event.Metadata.
+type Metadata struct {
+	EventID       uuid.UUID         `json:"event_id"`                 // unique event identifier
+	CorrelationID string            `json:"correlation_id,omitempty"` // identifier shared by all events of a single workflow
+	CausationID   string            `json:"causation_id,omitempty"`   // identifier of the event or request which caused the event
+	Actor         string            `json:"actor,omitempty"`          // user or service on whose behalf the event was produced
+	SchemaVersion int               `json:"schema_version,omitempty"` // version of Data schema
+	Headers       map[string]string `json:"headers,omitempty"`        // custom headers
+	TraceParent   string            `json:"traceparent,omitempty"`    // W3C trace context the event was produced within
+}
+
+// ParseMetadata parses metadata encoded in event Metadata, empty md results in zero Metadata.
+func ParseMetadata(md []byte) (Metadata, error) {
+	var m Metadata
+	if len(md) == 0 {
+		return m, nil
+	}
+	if err := json.Unmarshal(md, &m); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// SchemaVersioner is implemented by event data types reporting version of their schema.
+type SchemaVersioner interface {
+	SchemaVersion() int
+}
+
+// contextKey is a type of keys used to store values in context.
+type contextKey int
+
+const (
+	causeKey contextKey = iota
+)
+
+// cause holds metadata propagated to events produced within a context.
+type cause struct {
+	correlationID string
+	causationID   string
+	actor         string
+	headers       map[string]string
+}
+
+// causeFromContext returns a copy of cause stored in ctx.
+func causeFromContext(ctx context.Context) cause {
+	c, _ := ctx.Value(causeKey).(cause)
+	headers := make(map[string]string, len(c.headers))
+	for k, v := range c.headers {
+		headers[k] = v
+	}
+	c.headers = headers
+	return c
+}
+
+// ContextWithCorrelationID returns a copy of ctx carrying correlation id of events produced within it,
+// e.g. id of the request being handled.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	c := causeFromContext(ctx)
+	c.correlationID = id
+	return context.WithValue(ctx, causeKey, c)
+}
+
+// ContextWithCausationID returns a copy of ctx carrying causation id of events produced within it.
+func ContextWithCausationID(ctx context.Context, id string) context.Context {
+	c := causeFromContext(ctx)
+	c.causationID = id
+	return context.WithValue(ctx, causeKey, c)
+}
+
+// ContextWithActor returns a copy of ctx carrying actor of events produced within it.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	c := causeFromContext(ctx)
+	c.actor = actor
+	return context.WithValue(ctx, causeKey, c)
+}
+
+// ContextWithHeader returns a copy of ctx carrying custom header of events produced within it.
+func ContextWithHeader(ctx context.Context, key, value string) context.Context {
+	c := causeFromContext(ctx)
+	c.headers[key] = value
+	return context.WithValue(ctx, causeKey, c)
+}
+
+// ContextWithMetadata returns a copy of ctx continuing the workflow of the event md belongs to,
+// events produced within it are caused by the event, share its correlation id, actor and headers.
+// Values missing from md, e.g. of events stored without metadata, are kept from ctx.
+func ContextWithMetadata(ctx context.Context, md Metadata) context.Context {
+	c := causeFromContext(ctx)
+	switch {
+	case md.CorrelationID != "":
+		c.correlationID = md.CorrelationID
+	case md.EventID != uuid.Nil:
+		c.correlationID = md.EventID.String()
+	}
+	if md.EventID != uuid.Nil {
+		c.causationID = md.EventID.String()
+	}
+	if md.Actor != "" {
+		c.actor = md.Actor
+	}
+	for k, v := range md.Headers {
+		c.headers[k] = v
+	}
+	return context.WithValue(ctx, causeKey, c)
+}
+
+// ContextWithEventMetadata returns a copy of ctx continuing the trace and workflow stored in
+// encoded event metadata md, events produced within returned context are caused by the event.
+func ContextWithEventMetadata(ctx context.Context, md []byte) context.Context {
+	ctx = trace.ExtractMetadata(ctx, md)
+	if meta, err := ParseMetadata(md); err == nil {
+		ctx = ContextWithMetadata(ctx, meta)
+	}
+	return ctx
+}
+
+// MetadataFromContext returns metadata of an event produced within ctx.
+// Events starting a new workflow are correlated by their own id.
+func MetadataFromContext(ctx context.Context, data MarshalUnmarshaler) Metadata {
+	c := causeFromContext(ctx)
+
+	md := Metadata{
+		EventID:       uuid.New(),
+		CorrelationID: c.correlationID,
+		CausationID:   c.causationID,
+		Actor:         c.actor,
+	}
+	if md.CorrelationID == "" {
+		md.CorrelationID = md.EventID.String()
+	}
+	if len(c.headers) > 0 {
+		md.Headers = c.headers
+	}
+	if v, ok := data.(SchemaVersioner); ok {
+		md.SchemaVersion = v.SchemaVersion()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		md.TraceParent = sc.TraceParent()
+	}
+
+	return md
+}
@@ -0,0 +1,133 @@
+package es
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+type testVersionedEvent struct {
+	testIncCounter
+}
+
+// Implements es.SchemaVersioner
+func (t *testVersionedEvent) SchemaVersion() int {
+	return 2
+}
+
+// TestNewEventMetadata verifies new events start a workflow correlated by their own id.
+func TestNewEventMetadata(t *testing.T) {
+	e := NewEvent("1", &AggregateRoot{}, &testVersionedEvent{})
+
+	md, err := e.Meta()
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if md.EventID == uuid.Nil {
+		t.Errorf("got %v, want non nil event id", md.EventID)
+	}
+	if got, want := md.CorrelationID, md.EventID.String(); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := md.CausationID, ""; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := md.SchemaVersion, 2; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestMetadataPropagation verifies events produced while handling an event carry its ids.
+func TestMetadataPropagation(t *testing.T) {
+	ctx := ContextWithCorrelationID(context.Background(), "request-1")
+	ctx = ContextWithActor(ctx, "user-1")
+	ctx = ContextWithHeader(ctx, "tenant", "acme")
+
+	first := NewEventContext(ctx, "1", &AggregateRoot{}, &testIncCounter{})
+	firstMD, err := first.Meta()
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	second := NewEventContext(first.Context(context.Background()), "2", &AggregateRoot{}, &testIncCounter{})
+	secondMD, err := second.Meta()
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	// events stored without metadata keep correlation of the context
+	third := NewEventContext(ContextWithMetadata(ctx, Metadata{Actor: "user-2"}), "3", &AggregateRoot{}, &testIncCounter{})
+	thirdMD, err := third.Meta()
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	var testcases = []struct {
+		md   Metadata
+		want Metadata
+	}{
+		{
+			md: firstMD,
+			want: Metadata{
+				EventID:       firstMD.EventID,
+				CorrelationID: "request-1",
+				Actor:         "user-1",
+				Headers:       map[string]string{"tenant": "acme"},
+			},
+		},
+		{
+			md: secondMD,
+			want: Metadata{
+				EventID:       secondMD.EventID,
+				CorrelationID: "request-1",
+				CausationID:   firstMD.EventID.String(),
+				Actor:         "user-1",
+				Headers:       map[string]string{"tenant": "acme"},
+			},
+		},
+		{
+			md: thirdMD,
+			want: Metadata{
+				EventID:       thirdMD.EventID,
+				CorrelationID: "request-1",
+				Actor:         "user-2",
+				Headers:       map[string]string{"tenant": "acme"},
+			},
+		},
+	}
+
+	for i, tt := range testcases {
+		if !cmp.Equal(tt.md, tt.want) {
+			t.Errorf("#%d got %v, want %v", i, tt.md, tt.want)
+		}
+	}
+	if firstMD.EventID == secondMD.EventID {
+		t.Errorf("got %v, want unique event ids", secondMD.EventID)
+	}
+}
+
+// TestContextWithHeaderIsolation verifies derived contexts do not share headers.
+func TestContextWithHeaderIsolation(t *testing.T) {
+	parent := ContextWithHeader(context.Background(), "a", "1")
+	_ = ContextWithHeader(parent, "b", "2")
+
+	md := MetadataFromContext(parent, &testIncCounter{})
+	if got, want := md.Headers, map[string]string{"a": "1"}; !cmp.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestNewEventID verifies event ID matches ID stored in its Metadata.
+func TestNewEventID(t *testing.T) {
+	e := NewEvent("1", &AggregateRoot{}, &testIncCounter{})
+
+	md, err := e.Meta()
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if got, want := e.ID, md.EventID; got != want || got == uuid.Nil {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
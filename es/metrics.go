@@ -0,0 +1,39 @@
+package es
+
+import (
+	"time"
+
+	"github.com/deividaspetraitis/go/metrics"
+)
+
+var (
+	replayDuration = metrics.NewHistogram(
+		"aggregate_replay_duration_seconds",
+		"Duration of replaying events onto aggregate.",
+		metrics.DefBuckets,
+		"aggregate",
+	)
+	replayEvents = metrics.NewCounter(
+		"aggregate_replayed_events_total",
+		"Total number of events replayed onto aggregates.",
+		"aggregate",
+	)
+)
+
+func init() {
+	metrics.Register(replayDuration, replayEvents)
+}
+
+// Replay reconstructs agg state from events recording replay duration.
+func Replay(agg Aggregate, events []*Event) error {
+	start := time.Now()
+	if err := agg.Reply(events); err != nil {
+		return err
+	}
+
+	name := ParseAggregateName(agg)
+	replayDuration.ObserveSince(start, name)
+	replayEvents.Add(float64(len(events)), name)
+
+	return nil
+}
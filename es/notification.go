@@ -0,0 +1,39 @@
+package es
+
+import (
+	"context"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// ErrInvalidPosition is returned by Notifier when notifications can not be resumed from given position.
+var ErrInvalidPosition = errors.New("invalid position")
+
+// Notification describes an event committed to an event store independently of the store,
+// it is delivered to consumers such as HTTP clients.
+type Notification struct {
+	Position    string    `json:"-"` // store position of the event, notifications are resumed after it
+	AggregateID string    `json:"aggregate_id"`
+	Aggregate   string    `json:"aggregate"`
+	Version     Version   `json:"version"`
+	Type        string    `json:"type"`
+	Timestamp   time.Time `json:"timestamp"`
+	Data        any       `json:"data"` // JSON encodable event data
+}
+
+// Notifier is any type capable to notify about committed events.
+type Notifier interface {
+	// Notify returns channel of notifications about events committed after position,
+	// or only about new events if position is empty. Channel is closed once ctx is done
+	// or notifications can not be delivered any longer.
+	Notify(ctx context.Context, after string) (<-chan Notification, error)
+}
+
+// NotifierFunc is an adapter to allow the use of ordinary functions as Notifier.
+type NotifierFunc func(ctx context.Context, after string) (<-chan Notification, error)
+
+// Notify implements Notifier.
+func (f NotifierFunc) Notify(ctx context.Context, after string) (<-chan Notification, error) {
+	return f(ctx, after)
+}
@@ -8,12 +8,20 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// Health endpoints paths.
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
 // App is the entrypoint into our application and what configures our context
 // object for each of our http handlers. Feel free to add any configuration
 // data/logic on this App struct
 type App struct {
-	API      *mux.Router
-	shutdown chan os.Signal
+	API        *mux.Router
+	shutdown   chan os.Signal
+	health     *health
+	operations operations
 }
 
 // NewApp creates an App value that handle a set of routes for the application.
@@ -21,7 +29,14 @@ func NewApp(shutdown chan os.Signal) *App {
 	api := App{
 		API:      mux.NewRouter(),
 		shutdown: shutdown,
+		health:   &health{},
 	}
+
+	api.API.Use(traced, instrument)
+
+	api.API.HandleFunc(LivenessPath, api.health.liveHandler).Methods(http.MethodGet, http.MethodHead)
+	api.API.HandleFunc(ReadinessPath, api.health.readyHandler).Methods(http.MethodGet, http.MethodHead)
+
 	return &api
 }
 
@@ -30,8 +45,29 @@ func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	a.API.ServeHTTP(w, r)
 }
 
+// AddLivenessCheck registers Checker reported by liveness endpoint.
+func (a *App) AddLivenessCheck(name string, c Checker, opts ...CheckOption) {
+	a.health.mu.Lock()
+	a.health.liveness = append(a.health.liveness, newCheck(name, c, opts...))
+	a.health.mu.Unlock()
+}
+
+// AddReadinessCheck registers Checker reported by readiness endpoint.
+func (a *App) AddReadinessCheck(name string, c Checker, opts ...CheckOption) {
+	a.health.mu.Lock()
+	a.health.readiness = append(a.health.readiness, newCheck(name, c, opts...))
+	a.health.mu.Unlock()
+}
+
+// StartShutdown marks the app as shutting down, from this point on readiness
+// endpoint reports failure so that no new traffic is routed to the app.
+func (a *App) StartShutdown() {
+	a.health.shuttingDown.Store(true)
+}
+
 // SignalShutdown is used to gracefully shutdown the app when an integrity
 // issue is identified.
 func (a *App) SignalShutdown() {
+	a.StartShutdown()
 	a.shutdown <- syscall.SIGTERM
 }
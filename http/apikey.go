@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// DefaultAPIKeyHeader is the header API keys are read from by default.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyExpired  = errors.New("api key expired")
+)
+
+// APIKey represents identity associated with an API key.
+type APIKey struct {
+	Subject   string
+	Roles     []string
+	Scopes    []string
+	ExpiresAt time.Time // zero value means key does not expire
+}
+
+// APIKeyStore is any type capable to look up API keys.
+type APIKeyStore interface {
+	// LookupAPIKey returns identity associated with key or ErrAPIKeyNotFound.
+	LookupAPIKey(ctx context.Context, key string) (*APIKey, error)
+}
+
+// MemoryAPIKeyStore is in-memory APIKeyStore, keys are kept hashed.
+type MemoryAPIKeyStore struct {
+	mu   sync.RWMutex // guard fields below
+	keys map[[sha256.Size]byte]*APIKey
+}
+
+// NewMemoryAPIKeyStore constructs and returns new MemoryAPIKeyStore instance.
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{
+		keys: make(map[[sha256.Size]byte]*APIKey),
+	}
+}
+
+// Add adds key associated with identity k.
+func (s *MemoryAPIKeyStore) Add(key string, k *APIKey) {
+	s.mu.Lock()
+	s.keys[sha256.Sum256([]byte(key))] = k
+	s.mu.Unlock()
+}
+
+// Remove removes key.
+func (s *MemoryAPIKeyStore) Remove(key string) {
+	s.mu.Lock()
+	delete(s.keys, sha256.Sum256([]byte(key)))
+	s.mu.Unlock()
+}
+
+// LookupAPIKey implements APIKeyStore.
+func (s *MemoryAPIKeyStore) LookupAPIKey(ctx context.Context, key string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	k, ok := s.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return k, nil
+}
+
+// APIKeyAuthenticator implements Authenticator authenticating requests by API key.
+type APIKeyAuthenticator struct {
+	store  APIKeyStore
+	header string
+
+	// Returns the current time. Defaults to time.Now()
+	// Can be mocked for tests
+	Now func() time.Time
+}
+
+// NewAPIKeyAuthenticator constructs and returns new APIKeyAuthenticator reading
+// API keys from header, if header is empty DefaultAPIKeyHeader is used.
+func NewAPIKeyAuthenticator(store APIKeyStore, header string) *APIKeyAuthenticator {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+	return &APIKeyAuthenticator{
+		store:  store,
+		header: header,
+		Now:    time.Now,
+	}
+}
+
+// Authenticate implements Authenticator.
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(a.header)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	k, err := a.store.LookupAPIKey(r.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !k.ExpiresAt.IsZero() && !a.Now().Before(k.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	return &Principal{
+		Subject: k.Subject,
+		Method:  AuthMethodAPIKey,
+		Roles:   k.Roles,
+		Scopes:  k.Scopes,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/deividaspetraitis/go/errors"
+
+	"golang.org/x/exp/slices"
+)
+
+var (
+	// ErrNoCredentials is returned by Authenticator when request carries no credentials it understands.
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrUnauthenticated is rendered when request could not be authenticated.
+	ErrUnauthenticated = errors.New("unauthenticated")
+)
+
+// Authentication methods.
+const (
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "apikey"
+)
+
+// Principal represents authenticated identity of a request.
+type Principal struct {
+	Subject string         // Unique subject identifier
+	Method  string         // Authentication method used
+	Roles   []string       // Granted roles
+	Scopes  []string       // Granted scopes
+	Claims  map[string]any // Raw token claims if any
+}
+
+// HasRole reports whether principal is granted role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope reports whether principal is granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Authenticator is any type capable to authenticate HTTP request.
+type Authenticator interface {
+	// Authenticate returns principal of authenticated request.
+	// ErrNoCredentials is returned if request carries no credentials supported by authenticator.
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions as Authenticator.
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+// Authenticate implements Authenticator.
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+// Authenticate returns middleware requiring request to be authenticated by one of authenticators.
+// Authenticators are tried in order, first one finding credentials decides the outcome.
+// Authenticated principal is available by PrincipalFromContext.
+func Authenticate(authenticators ...Authenticator) func(http.Handler) http.Handler {
+	return authenticate(true, authenticators)
+}
+
+// AuthenticateOptional is like Authenticate but lets requests without credentials through.
+// Requests carrying invalid credentials are still rejected.
+func AuthenticateOptional(authenticators ...Authenticator) func(http.Handler) http.Handler {
+	return authenticate(false, authenticators)
+}
+
+// authenticate returns authentication middleware.
+func authenticate(required bool, authenticators []Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				p, err := a.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					unauthenticated(w, r, err)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), p)))
+				return
+			}
+
+			if required {
+				unauthenticated(w, r, ErrUnauthenticated)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unauthenticated renders 401 response.
+func unauthenticated(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	RenderError(w, r, http.StatusUnauthorized, errors.Wrap(err, ErrUnauthenticated.Error()))
+}
+
+// bearerToken returns Bearer token of the request.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
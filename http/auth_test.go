@@ -0,0 +1,119 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// TestAPIKeyAuthenticator verifies API key lookup and expiry.
+func TestAPIKeyAuthenticator(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	store := NewMemoryAPIKeyStore()
+	store.Add("valid", &APIKey{Subject: "service", Roles: []string{"admin"}})
+	store.Add("expired", &APIKey{Subject: "service", ExpiresAt: now})
+	store.Add("removed", &APIKey{Subject: "service"})
+	store.Remove("removed")
+
+	a := NewAPIKeyAuthenticator(store, "")
+	a.Now = func() time.Time { return now }
+
+	var testcases = []struct {
+		key string
+
+		subject string
+		err     error
+	}{
+		{key: "", err: ErrNoCredentials},
+		{key: "unknown", err: ErrAPIKeyNotFound},
+		{key: "removed", err: ErrAPIKeyNotFound},
+		{key: "expired", err: ErrAPIKeyExpired},
+		{key: "valid", subject: "service"},
+	}
+
+	for i, tt := range testcases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.key != "" {
+			r.Header.Set(DefaultAPIKeyHeader, tt.key)
+		}
+
+		p, err := a.Authenticate(r)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if p.Subject != tt.subject || p.Method != AuthMethodAPIKey || !p.HasRole("admin") {
+			t.Errorf("#%d got %+v, want %v", i, p, tt.subject)
+		}
+	}
+}
+
+// TestAuthenticate verifies authentication middleware outcomes.
+func TestAuthenticate(t *testing.T) {
+	store := NewMemoryAPIKeyStore()
+	store.Add("valid", &APIKey{Subject: "service"})
+	apikey := NewAPIKeyAuthenticator(store, "")
+
+	failing := AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		if r.Header.Get("X-Fail") == "" {
+			return nil, ErrNoCredentials
+		}
+		return nil, errors.New("invalid credentials")
+	})
+
+	var testcases = []struct {
+		required bool
+		key      string
+		fail     bool
+
+		status  int
+		subject string
+	}{
+		{required: true, status: http.StatusUnauthorized},
+		{required: true, key: "unknown", status: http.StatusUnauthorized},
+		{required: true, key: "valid", status: http.StatusOK, subject: "service"},
+		{required: true, key: "valid", fail: true, status: http.StatusUnauthorized}, // first authenticator finding credentials decides
+		{required: false, status: http.StatusOK},
+		{required: false, key: "unknown", status: http.StatusUnauthorized},
+		{required: false, key: "valid", status: http.StatusOK, subject: "service"},
+	}
+
+	for i, tt := range testcases {
+		mw := AuthenticateOptional(failing, apikey)
+		if tt.required {
+			mw = Authenticate(failing, apikey)
+		}
+
+		var subject string
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject, _ = SubjectFromContext(r.Context())
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.key != "" {
+			r.Header.Set(DefaultAPIKeyHeader, tt.key)
+		}
+		if tt.fail {
+			r.Header.Set("X-Fail", "1")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := subject, tt.subject; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := w.Header().Get("WWW-Authenticate") != "", tt.status == http.StatusUnauthorized; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
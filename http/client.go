@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/deividaspetraitis/go/errors"
-	"github.com/deividaspetraitis/go/log"
+	"github.com/deividaspetraitis/go/trace"
 )
 
 // userAgent is the default user agent.
@@ -17,7 +20,7 @@ var userAgent = fmt.Sprintln("go")
 
 // RequestOption is modifier of a request.
 type RequestOption interface {
-	apply(*http.Request)
+	apply(*http.Request) error
 }
 
 // newRequestOption constructs a new requestOption.
@@ -31,8 +34,9 @@ type requestOption struct {
 }
 
 // apply implements RequestOption.
-func (o *requestOption) apply(r *http.Request) {
+func (o *requestOption) apply(r *http.Request) error {
 	o.applyFn(r)
+	return nil
 }
 
 // WithParameter constructs RequestOption to add arbitrary query parameters to the request.
@@ -63,7 +67,7 @@ func WithBearerToken(token string) RequestOption {
 type Client struct {
 	url            *url.URL
 	userAgent      string
-	debug          bool
+	debug          *debugger
 	http           *http.Client
 	requestOptions []RequestOption
 }
@@ -78,12 +82,18 @@ func NewClient(domain string, opts ...RequestOption) (*Client, error) {
 	return &Client{
 		url:            u,
 		userAgent:      userAgent,
-		debug:          false,
 		http:           http.DefaultClient,
 		requestOptions: opts,
 	}, nil
 }
 
+// SetTransport replaces http.RoundTripper used to send client requests.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	hc := *c.http
+	hc.Transport = rt
+	c.http = &hc
+}
+
 // SetRequestOption replaces RequestOption's for the client reused across all client issued requests.
 func (c *Client) SetRequestOption(r ...RequestOption) {
 	c.requestOptions = r
@@ -110,23 +120,31 @@ func (c *Client) URI(path ...string) string {
 }
 
 // NewRequest returns a new HTTP request. If the payload is not nil it will be encoded as JSON.
-func (c *Client) request(ctx context.Context, method, uri string, payload []byte, options ...RequestOption) (r *http.Request, err error) {
-	r, err = http.NewRequestWithContext(ctx, method, uri, bytes.NewReader(payload))
+func (c *Client) request(ctx context.Context, method, uri string, body io.Reader, options ...RequestOption) (r *http.Request, err error) {
+	r, err = http.NewRequestWithContext(ctx, method, uri, body)
 	if err != nil {
 		return nil, err
 	}
 
-	// Apply global client options
-	for _, opt := range c.requestOptions {
-		if opt != nil {
-			opt.apply(r)
+	// Apply global client options followed by request specific options,
+	// credentials are applied last as they may sign the final request.
+	var credentials []RequestOption
+	for _, opt := range append(c.requestOptions[:len(c.requestOptions):len(c.requestOptions)], options...) {
+		if opt == nil {
+			continue
+		}
+		if _, ok := opt.(*credentialsOption); ok {
+			credentials = append(credentials, opt)
+			continue
+		}
+		if err := opt.apply(r); err != nil {
+			return nil, err
 		}
 	}
 
-	// Apply request specific options
-	for _, opt := range options {
-		if opt != nil {
-			opt.apply(r)
+	for _, opt := range credentials {
+		if err := opt.apply(r); err != nil {
+			return nil, errors.Wrapf(err, "applying credentials")
 		}
 	}
 
@@ -136,9 +154,32 @@ func (c *Client) request(ctx context.Context, method, uri string, payload []byte
 // Request combines request and do, while also handling decoding of response
 // payload.
 func (c *Client) Request(ctx context.Context, method, uri string, v []byte, options ...RequestOption) (*http.Response, error) {
+	return c.Stream(ctx, method, uri, bytes.NewReader(v), options...)
+}
+
+// Stream is like Request but streams request body from r instead of buffering it.
+// Length of *bytes.Buffer, *bytes.Reader and *strings.Reader bodies is known upfront,
+// other bodies are sent using chunked encoding unless WithContentLength is given.
+func (c *Client) Stream(ctx context.Context, method, uri string, r io.Reader, options ...RequestOption) (*http.Response, error) {
+	res, err := c.send(ctx, method, uri, r, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return res, errors.Newf("request resulted in %d response code", res.StatusCode)
+	}
+
+	return res, nil
+}
+
+// send builds and sends request to uri, the request is retried once if credentials were rejected
+// and body can be sent again.
+func (c *Client) send(ctx context.Context, method, uri string, body io.Reader, options ...RequestOption) (*http.Response, error) {
 	uri = c.URI(uri)
 
-	req, err := c.request(ctx, method, uri, v, options...)
+	req, err := c.request(ctx, method, uri, body, options...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "building request")
 	}
@@ -148,13 +189,26 @@ func (c *Client) Request(ctx context.Context, method, uri string, v []byte, opti
 		return nil, errors.Wrapf(err, "sending request to %s", uri)
 	}
 
-	if c.debug {
-		log.Printf("request to %s resulted in HTTP response code %d", req.URL.String(), res.StatusCode)
-	}
-
-	if res.StatusCode != http.StatusOK {
+	// retry once with refreshed credentials if they were rejected
+	if res.StatusCode == http.StatusUnauthorized && (req.GetBody != nil || req.Body == nil || req.Body == http.NoBody) && c.invalidate(options) {
 		res.Body.Close()
-		return res, errors.Newf("request resulted in %d response code", res.StatusCode)
+
+		body = nil
+		if req.GetBody != nil {
+			if body, err = req.GetBody(); err != nil {
+				return nil, errors.Wrapf(err, "building request")
+			}
+		}
+
+		req, err = c.request(ctx, method, uri, body, options...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building request")
+		}
+
+		res, err = c.do(req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sending request to %s", uri)
+		}
 	}
 
 	return res, nil
@@ -163,10 +217,30 @@ func (c *Client) Request(ctx context.Context, method, uri string, v []byte, opti
 // do sends an HTTP request and returns an HTTP response, handling any context
 // cancellations or timeouts.
 func (c *Client) do(req *http.Request) (*http.Response, error) {
-	ctx := req.Context()
+	ctx, span := trace.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithKind(trace.KindClient),
+		trace.WithAttribute("http.method", req.Method),
+		trace.WithAttribute("http.url", req.URL.String()),
+	)
+	defer span.Finish()
+
+	req = req.WithContext(ctx)
+	trace.Inject(ctx, req.Header)
+
+	var debug *debugRecord
+	if c.debug != nil {
+		req, debug = c.debug.start(req)
+	}
+
+	start := time.Now()
 
 	res, err := c.http.Do(req)
+	if debug != nil {
+		res = debug.finish(res, err)
+	}
 	if err != nil {
+		span.SetError(err)
+		clientDuration.ObserveSince(start, req.Method, req.URL.Host, "error")
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
@@ -175,5 +249,10 @@ func (c *Client) do(req *http.Request) (*http.Response, error) {
 		}
 	}
 
+	withDownloadProgress(req, res)
+
+	span.SetAttribute("http.status_code", strconv.Itoa(res.StatusCode))
+	clientDuration.ObserveSince(start, req.Method, req.URL.Host, strconv.Itoa(res.StatusCode))
+
 	return res, nil
 }
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestNegotiateEncoding verifies content encoding negotiation by Accept-Encoding header.
+func TestNegotiateEncoding(t *testing.T) {
+	var testcases = []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "gzip", want: EncodingGzip},
+		{header: "deflate", want: EncodingDeflate},
+		{header: "gzip, deflate", want: EncodingGzip},
+		{header: "gzip;q=0.5, deflate", want: EncodingDeflate},
+		{header: "br", want: ""},
+		{header: "identity", want: ""},
+		{header: "*", want: EncodingGzip},
+		{header: "gzip;q=0, *", want: EncodingDeflate},
+		{header: "*, gzip;q=0", want: EncodingDeflate},
+		{header: "gzip;q=0, deflate;q=0, *", want: ""},
+		{header: "*;q=0", want: ""},
+		{header: "GZIP", want: EncodingGzip},
+	}
+
+	for i, tt := range testcases {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("#%d got %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+// TestCompress verifies responses are compressed with negotiated encoding.
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("compressible ", 100)
+
+	var testcases = []struct {
+		method         string
+		acceptEncoding string
+		status         int
+
+		encoding string
+	}{
+		{method: http.MethodGet, acceptEncoding: "gzip", status: http.StatusOK, encoding: EncodingGzip},
+		{method: http.MethodGet, acceptEncoding: "deflate", status: http.StatusOK, encoding: EncodingDeflate},
+		{method: http.MethodGet, acceptEncoding: "", status: http.StatusOK, encoding: ""},
+		{method: http.MethodGet, acceptEncoding: "gzip;q=0", status: http.StatusOK, encoding: ""},
+		{method: http.MethodGet, acceptEncoding: "gzip", status: http.StatusNoContent, encoding: ""},
+		{method: http.MethodHead, acceptEncoding: "gzip", status: http.StatusOK, encoding: ""},
+	}
+
+	for i, tt := range testcases {
+		handler := Compress(gzip.BestSpeed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			if tt.status != http.StatusNoContent {
+				io.WriteString(w, body)
+			}
+		}))
+
+		r := httptest.NewRequest(tt.method, "/", nil)
+		if tt.acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got, want := w.Header().Get("Content-Encoding"), tt.encoding; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := w.Header().Get("Vary"), "Accept-Encoding"; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+
+		var rd io.Reader = w.Body
+		switch tt.encoding {
+		case EncodingGzip:
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("#%d got %v, want %v", i, err, nil)
+			}
+			rd = zr
+		case EncodingDeflate:
+			zr, err := zlib.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("#%d got %v, want %v", i, err, nil)
+			}
+			rd = zr
+		}
+
+		b, err := io.ReadAll(rd)
+		if err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+		if want := body; tt.status == http.StatusOK && tt.method == http.MethodGet && string(b) != want {
+			t.Errorf("#%d got %v, want %v", i, len(b), len(want))
+		}
+	}
+}
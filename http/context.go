@@ -0,0 +1,31 @@
+package http
+
+import "context"
+
+// contextKey is a type of keys used to store values in request context.
+type contextKey int
+
+const (
+	principalKey contextKey = iota
+	downloadProgressKey
+)
+
+// ContextWithPrincipal returns a copy of ctx carrying authenticated principal.
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext returns authenticated principal stored in ctx.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+	return p, ok && p != nil
+}
+
+// SubjectFromContext returns subject of authenticated principal stored in ctx.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok || p.Subject == "" {
+		return "", false
+	}
+	return p.Subject, true
+}
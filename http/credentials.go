@@ -0,0 +1,381 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// CredentialProvider is any type capable to authenticate outbound requests.
+type CredentialProvider interface {
+	Authorize(r *http.Request) error
+}
+
+// CredentialProviderFunc is an adapter to allow the use of ordinary functions as CredentialProvider.
+type CredentialProviderFunc func(r *http.Request) error
+
+// Authorize implements CredentialProvider.
+func (f CredentialProviderFunc) Authorize(r *http.Request) error {
+	return f(r)
+}
+
+// Invalidator is implemented by CredentialProvider's able to refresh credentials rejected by a server.
+type Invalidator interface {
+	// Invalidate discards cached credentials, they are obtained again on the next request.
+	Invalidate()
+}
+
+// credentialsOption is RequestOption applying credentials of a provider.
+// It is applied after all other options.
+type credentialsOption struct {
+	provider CredentialProvider
+}
+
+// apply implements RequestOption.
+func (o *credentialsOption) apply(r *http.Request) error {
+	return o.provider.Authorize(r)
+}
+
+// WithCredentials constructs RequestOption to authenticate request using p.
+// If p implements Invalidator, request rejected with 401 is retried once with refreshed credentials.
+func WithCredentials(p CredentialProvider) RequestOption {
+	return &credentialsOption{provider: p}
+}
+
+// invalidate invalidates credentials used by the client and options, it reports whether any were invalidated.
+func (c *Client) invalidate(options []RequestOption) bool {
+	var invalidated bool
+	for _, opt := range append(c.requestOptions[:len(c.requestOptions):len(c.requestOptions)], options...) {
+		if o, ok := opt.(*credentialsOption); ok {
+			if i, ok := o.provider.(Invalidator); ok {
+				i.Invalidate()
+				invalidated = true
+			}
+		}
+	}
+	return invalidated
+}
+
+// BasicAuth returns CredentialProvider authenticating requests using HTTP Basic authentication.
+func BasicAuth(username, password string) CredentialProvider {
+	return CredentialProviderFunc(func(r *http.Request) error {
+		r.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// ErrTokenRequest is returned when OAuth2 token can not be obtained.
+var ErrTokenRequest = errors.New("oauth2: token request failed")
+
+const defaultOAuth2Leeway = 30 * time.Second // default time before expiry token is refreshed
+
+// OAuth2Option is modifier of OAuth2 client credentials provider.
+type OAuth2Option interface {
+	apply(*OAuth2ClientCredentials)
+}
+
+// newOAuth2Option constructs a new oauth2Option.
+func newOAuth2Option(fn func(o *OAuth2ClientCredentials)) *oauth2Option {
+	return &oauth2Option{applyFn: fn}
+}
+
+// oauth2Option is an implementation of OAuth2Option.
+type oauth2Option struct {
+	applyFn func(o *OAuth2ClientCredentials)
+}
+
+// apply implements OAuth2Option.
+func (o *oauth2Option) apply(c *OAuth2ClientCredentials) {
+	o.applyFn(c)
+}
+
+// WithOAuth2Scopes constructs OAuth2Option requesting tokens with scopes.
+func WithOAuth2Scopes(scopes ...string) OAuth2Option {
+	return newOAuth2Option(func(o *OAuth2ClientCredentials) {
+		o.scopes = scopes
+	})
+}
+
+// WithOAuth2Params constructs OAuth2Option adding params to token requests, ie. audience.
+func WithOAuth2Params(params url.Values) OAuth2Option {
+	return newOAuth2Option(func(o *OAuth2ClientCredentials) {
+		o.params = params
+	})
+}
+
+// WithOAuth2Leeway constructs OAuth2Option to refresh tokens d before they expire.
+func WithOAuth2Leeway(d time.Duration) OAuth2Option {
+	return newOAuth2Option(func(o *OAuth2ClientCredentials) {
+		o.leeway = d
+	})
+}
+
+// WithOAuth2HTTPClient constructs OAuth2Option to request tokens using client.
+func WithOAuth2HTTPClient(client *http.Client) OAuth2Option {
+	return newOAuth2Option(func(o *OAuth2ClientCredentials) {
+		o.client = client
+	})
+}
+
+// OAuth2ClientCredentials is CredentialProvider obtaining bearer tokens using OAuth2 client credentials grant.
+// Tokens are cached and refreshed shortly before they expire.
+type OAuth2ClientCredentials struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	params       url.Values
+	leeway       time.Duration
+	client       *http.Client
+
+	Now func() time.Time // Can be mocked for tests.
+
+	mu      sync.Mutex // guard fields below
+	token   string
+	expires time.Time
+}
+
+// NewOAuth2ClientCredentials constructs a new OAuth2ClientCredentials requesting tokens from tokenURL.
+func NewOAuth2ClientCredentials(tokenURL, clientID, clientSecret string, opts ...OAuth2Option) *OAuth2ClientCredentials {
+	o := &OAuth2ClientCredentials{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		leeway:       defaultOAuth2Leeway,
+		client:       http.DefaultClient,
+		Now:          time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(o)
+		}
+	}
+	return o
+}
+
+// Authorize implements CredentialProvider.
+func (o *OAuth2ClientCredentials) Authorize(r *http.Request) error {
+	token, err := o.Token(r.Context())
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Invalidate implements Invalidator.
+func (o *OAuth2ClientCredentials) Invalidate() {
+	o.mu.Lock()
+	o.token, o.expires = "", time.Time{}
+	o.mu.Unlock()
+}
+
+// Token returns cached access token or requests a new one if cached token is about to expire.
+func (o *OAuth2ClientCredentials) Token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token != "" && o.Now().Add(o.leeway).Before(o.expires) {
+		return o.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.scopes) > 0 {
+		form.Set("scope", strings.Join(o.scopes, " "))
+	}
+	for k, v := range o.params {
+		form[k] = v
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", ContentTypeJSON)
+	req.SetBasicAuth(url.QueryEscape(o.clientID), url.QueryEscape(o.clientSecret))
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, ErrTokenRequest.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Wrapf(ErrTokenRequest, "token endpoint responded with %d", res.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		TokenType   string      `json:"token_type"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", errors.Wrap(err, ErrTokenRequest.Error())
+	}
+	if token.AccessToken == "" {
+		return "", errors.Wrapf(ErrTokenRequest, "token endpoint responded without access token")
+	}
+
+	now := o.Now()
+	o.token, o.expires = token.AccessToken, now.Add(time.Hour*24*365) // tokens without expiry are kept until rejected
+	if seconds, err := token.ExpiresIn.Int64(); err == nil && seconds > 0 {
+		o.expires = now.Add(time.Duration(seconds) * time.Second)
+	}
+
+	return o.token, nil
+}
+
+// HMAC signature headers.
+const (
+	SignatureHeader          = "Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	ContentSHA256Header      = "X-Content-SHA256"
+)
+
+// ErrInvalidRequestSignature is returned when request signature can not be verified.
+var ErrInvalidRequestSignature = errors.New("invalid request signature")
+
+// HMACSigner is CredentialProvider signing requests with HMAC-SHA256 over canonical request.
+//
+// Canonical request consists of newline separated method, path, sorted query, signed headers
+// formatted as lowercase "name:value" lines and hex encoded SHA-256 digest of the body.
+type HMACSigner struct {
+	keyID   string
+	secret  []byte
+	headers []string
+
+	Now func() time.Time // Can be mocked for tests.
+}
+
+// NewHMACSigner constructs a new HMACSigner, signature covers host, timestamp and body digest headers
+// along with given additional headers.
+func NewHMACSigner(keyID string, secret []byte, headers ...string) *HMACSigner {
+	signed := []string{"host", strings.ToLower(SignatureTimestampHeader), strings.ToLower(ContentSHA256Header)}
+	for _, h := range headers {
+		signed = append(signed, strings.ToLower(h))
+	}
+	return &HMACSigner{
+		keyID:   keyID,
+		secret:  secret,
+		headers: signed,
+		Now:     time.Now,
+	}
+}
+
+// Authorize implements CredentialProvider.
+func (s *HMACSigner) Authorize(r *http.Request) error {
+	body, err := requestBody(r)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(body)
+	r.Header.Set(SignatureTimestampHeader, strconv.FormatInt(s.Now().Unix(), 10))
+	r.Header.Set(ContentSHA256Header, hex.EncodeToString(digest[:]))
+
+	r.Header.Set(SignatureHeader, `keyId="`+s.keyID+`",algorithm="hmac-sha256",headers="`+
+		strings.Join(s.headers, " ")+`",signature="`+s.sign(r, s.headers, digest[:])+`"`)
+
+	return nil
+}
+
+// Verify verifies signature of r signed by key identified by keyID, signatures older than maxAge are rejected.
+func (s *HMACSigner) Verify(r *http.Request, maxAge time.Duration) error {
+	params := parseSignature(r.Header.Get(SignatureHeader))
+	if params["keyId"] != s.keyID || params["algorithm"] != "hmac-sha256" {
+		return ErrInvalidRequestSignature
+	}
+
+	ts, err := strconv.ParseInt(r.Header.Get(SignatureTimestampHeader), 10, 64)
+	if err != nil || s.Now().Sub(time.Unix(ts, 0)).Abs() > maxAge {
+		return ErrInvalidRequestSignature
+	}
+
+	body, err := requestBody(r)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(body)
+	if hex.EncodeToString(digest[:]) != r.Header.Get(ContentSHA256Header) {
+		return ErrInvalidRequestSignature
+	}
+
+	headers := strings.Fields(params["headers"])
+	if !hmac.Equal([]byte(s.sign(r, headers, digest[:])), []byte(params["signature"])) {
+		return ErrInvalidRequestSignature
+	}
+
+	return nil
+}
+
+// sign returns base64 encoded signature of canonical request.
+func (s *HMACSigner) sign(r *http.Request, headers []string, digest []byte) string {
+	var b strings.Builder
+	b.WriteString(r.Method + "\n")
+	b.WriteString(r.URL.EscapedPath() + "\n")
+	b.WriteString(r.URL.Query().Encode() + "\n")
+	for _, h := range headers {
+		value := r.Header.Get(h)
+		if h == "host" {
+			value = r.Host
+			if value == "" {
+				value = r.URL.Host
+			}
+		}
+		b.WriteString(h + ":" + strings.TrimSpace(value) + "\n")
+	}
+	b.WriteString(hex.EncodeToString(digest))
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseSignature parses comma separated key="value" pairs of signature header.
+func parseSignature(header string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			params[k] = strings.Trim(v, `"`)
+		}
+	}
+	return params
+}
+
+// requestBody returns body of r leaving it intact for further reads.
+func requestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
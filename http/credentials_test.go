@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestCredentials verifies OAuth2 token caching, refresh on 401 and HMAC request signing.
+func TestCredentials(t *testing.T) {
+	var issued int
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "id" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		issued++
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":60}`, issued)
+	}))
+	defer tokens.Close()
+
+	signer := NewHMACSigner("key", []byte("secret"))
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := signer.Verify(r, time.Minute); err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		// first issued token is considered revoked
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	oauth := NewOAuth2ClientCredentials(tokens.URL, "id", "secret", WithOAuth2Scopes("read"))
+	client, err := NewClient(api.URL, WithCredentials(oauth), WithCredentials(signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testcases = []struct {
+		payload []byte
+		issued  int
+	}{
+		{payload: []byte(`{"a":1}`), issued: 2}, // token-1 rejected, refreshed
+		{payload: nil, issued: 2},               // token-2 is cached
+	}
+
+	for i, tt := range testcases {
+		res, err := client.Request(context.Background(), http.MethodPost, "orders", tt.payload, WithQueryParam("q", "1"))
+		if err != nil {
+			t.Fatalf("#%d got %v", i, err)
+		}
+		res.Body.Close()
+		if got, want := issued, tt.issued; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+
+	oauth.Now = func() time.Time { return time.Now().Add(time.Minute) }
+	if token, _ := oauth.Token(context.Background()); token != "token-3" {
+		t.Errorf("got %v, want %v", token, "token-3")
+	}
+}
@@ -0,0 +1,301 @@
+package http
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/go/log"
+)
+
+const defaultDebugBodyLimit = 1024 // default number of body bytes logged
+
+// DebugOption is modifier of client debug logging.
+type DebugOption interface {
+	apply(*debugger)
+}
+
+// newDebugOption constructs a new debugOption.
+func newDebugOption(fn func(d *debugger)) *debugOption {
+	return &debugOption{applyFn: fn}
+}
+
+// debugOption is an implementation of DebugOption.
+type debugOption struct {
+	applyFn func(d *debugger)
+}
+
+// apply implements DebugOption.
+func (o *debugOption) apply(d *debugger) {
+	o.applyFn(d)
+}
+
+// WithDebugBodyLimit constructs DebugOption to log at most n bytes of request and response bodies.
+// Zero n disables body logging.
+func WithDebugBodyLimit(n int) DebugOption {
+	return newDebugOption(func(d *debugger) {
+		d.bodyLimit = n
+	})
+}
+
+// WithDebugRedactHeaders constructs DebugOption to redact values of additional headers.
+// Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-API-Key and Signature headers are always redacted.
+func WithDebugRedactHeaders(names ...string) DebugOption {
+	return newDebugOption(func(d *debugger) {
+		d.redact = append(d.redact, names...)
+	})
+}
+
+// WithDebugRedactQuery constructs DebugOption to redact values of additional URL query parameters.
+// Parameters commonly carrying credentials, ie. access_token, api_key or signature, are always redacted.
+func WithDebugRedactQuery(names ...string) DebugOption {
+	return newDebugOption(func(d *debugger) {
+		d.redactQuery = append(d.redactQuery, names...)
+	})
+}
+
+// WithDebugLogger constructs DebugOption to emit request log entries using fn instead of package log.
+func WithDebugLogger(fn func(fields log.Fields)) DebugOption {
+	return newDebugOption(func(d *debugger) {
+		d.logFn = fn
+	})
+}
+
+// debugger logs requests sent by the client.
+type debugger struct {
+	bodyLimit   int
+	redact      []string
+	redactQuery []string
+	logFn       func(fields log.Fields)
+}
+
+// EnableDebug enables logging method, URL, headers, truncated bodies and timing breakdown
+// of every request sent by the client at debug level. Requests are logged once response body
+// is read to the end or closed, thus streamed responses are not delayed.
+func (c *Client) EnableDebug(opts ...DebugOption) {
+	d := &debugger{
+		bodyLimit: defaultDebugBodyLimit,
+		redact:    []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key", SignatureHeader},
+		redactQuery: []string{
+			"access_token", "api_key", "apikey", "client_secret", "code", "key",
+			"password", "secret", "sig", "signature", "token",
+		},
+		logFn: func(fields log.Fields) {
+			log.WithFields(fields).Debug("http client request")
+		},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(d)
+		}
+	}
+	c.debug = d
+}
+
+// DisableDebug disables request logging.
+func (c *Client) DisableDebug() {
+	c.debug = nil
+}
+
+// debugRecord collects details of a single request.
+type debugRecord struct {
+	debugger *debugger
+	start    time.Time
+
+	mu                               sync.Mutex // guard fields below, trace hooks may run concurrently
+	fields                           log.Fields
+	dnsStart, connectStart, tlsStart time.Time
+}
+
+// set sets field key to value.
+func (rec *debugRecord) set(key string, value any) {
+	rec.mu.Lock()
+	rec.fields[key] = value
+	rec.mu.Unlock()
+}
+
+// start starts recording req, it returns request to be sent instead of req.
+func (d *debugger) start(req *http.Request) (*http.Request, *debugRecord) {
+	rec := &debugRecord{
+		debugger: d,
+		start:    time.Now(),
+		fields: log.Fields{
+			"method":          req.Method,
+			"url":             d.url(req.URL),
+			"request_headers": d.headers(req.Header),
+		},
+	}
+
+	if d.bodyLimit > 0 && req.GetBody != nil { // streamed bodies are not buffered for logging
+		if body, err := requestBody(req); err == nil && len(body) > 0 {
+			rec.fields["request_body"] = truncate(body, d.bodyLimit)
+		}
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			rec.mu.Lock()
+			rec.dnsStart = time.Now()
+			rec.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			rec.mu.Lock()
+			rec.fields["dns"] = time.Since(rec.dnsStart).String()
+			rec.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			rec.mu.Lock()
+			rec.connectStart = time.Now()
+			rec.mu.Unlock()
+		},
+		ConnectDone: func(string, string, error) {
+			rec.mu.Lock()
+			rec.fields["connect"] = time.Since(rec.connectStart).String()
+			rec.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			rec.mu.Lock()
+			rec.tlsStart = time.Now()
+			rec.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			rec.mu.Lock()
+			rec.fields["tls"] = time.Since(rec.tlsStart).String()
+			rec.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			rec.set("conn_reused", info.Reused)
+		},
+		GotFirstResponseByte: func() {
+			rec.set("first_byte", time.Since(rec.start).String())
+		},
+	}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), rec
+}
+
+// finish logs outcome of recorded request, it returns response to be used instead of res.
+func (rec *debugRecord) finish(res *http.Response, err error) *http.Response {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.fields["duration"] = time.Since(rec.start).String()
+
+	if err != nil {
+		rec.fields["error"] = err.Error()
+		rec.debugger.logFn(rec.snapshot())
+		return res
+	}
+
+	rec.fields["status"] = res.StatusCode
+	rec.fields["response_headers"] = rec.debugger.headers(res.Header)
+
+	if limit := rec.debugger.bodyLimit; limit > 0 && res.Body != nil && res.Body != http.NoBody {
+		// body is captured as read by the caller, request is logged once it is read or closed
+		res.Body = &debugBody{ReadCloser: res.Body, rec: rec, limit: limit}
+		return res
+	}
+
+	rec.debugger.logFn(rec.snapshot())
+	return res
+}
+
+// debugBody is response body capturing its first bytes and logging recorded request
+// once read to the end or closed.
+type debugBody struct {
+	io.ReadCloser
+	rec   *debugRecord
+	limit int
+	head  []byte // at most limit+1 first bytes of body
+	once  sync.Once
+}
+
+// Read implements io.Reader.
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if rest := b.limit + 1 - len(b.head); rest > 0 && n > 0 {
+		b.head = append(b.head, p[:min(n, rest)]...)
+	}
+	if err != nil {
+		b.log(err)
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *debugBody) Close() error {
+	b.log(nil)
+	return b.ReadCloser.Close()
+}
+
+// log logs recorded request with captured body once.
+func (b *debugBody) log(err error) {
+	b.once.Do(func() {
+		b.rec.mu.Lock()
+		defer b.rec.mu.Unlock()
+
+		if len(b.head) > 0 {
+			b.rec.fields["response_body"] = truncate(b.head, b.limit)
+		}
+		if err != nil && err != io.EOF {
+			b.rec.fields["response_body_error"] = err.Error()
+		}
+		b.rec.debugger.logFn(b.rec.snapshot())
+	})
+}
+
+// snapshot returns copy of collected fields, rec.mu must be held.
+func (rec *debugRecord) snapshot() log.Fields {
+	fields := make(log.Fields, len(rec.fields))
+	for k, v := range rec.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// headers returns h formatted for logging with sensitive values redacted.
+func (d *debugger) headers(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+	for name, values := range h {
+		headers[name] = strings.Join(values, ", ")
+	}
+	for _, name := range d.redact {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := headers[name]; ok {
+			headers[name] = "REDACTED"
+		}
+	}
+	return headers
+}
+
+// url returns u formatted for logging with password and sensitive query values redacted.
+func (d *debugger) url(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+
+	cp := *u
+	query := cp.Query()
+	for name := range query {
+		for _, redacted := range d.redactQuery {
+			if strings.EqualFold(name, redacted) {
+				query[name] = []string{"REDACTED"}
+			}
+		}
+	}
+	cp.RawQuery = query.Encode()
+	return cp.Redacted()
+}
+
+// truncate returns b as string limited to n bytes.
+func truncate(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	return string(b[:n]) + "...(truncated)"
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/log"
+)
+
+// TestDebug verifies request logging with header redaction and body truncation.
+func TestDebug(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 10)))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, WithBearerToken("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields log.Fields
+	client.EnableDebug(WithDebugBodyLimit(4), WithDebugLogger(func(f log.Fields) {
+		fields = f
+	}))
+
+	res, err := client.Request(context.Background(), http.MethodPost, "debug", []byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	var testcases = []struct {
+		got  any
+		want any
+	}{
+		{got: string(body), want: strings.Repeat("x", 10)},
+		{got: fields["method"], want: http.MethodPost},
+		{got: fields["status"], want: http.StatusOK},
+		{got: fields["request_headers"].(map[string]string)["Authorization"], want: "REDACTED"},
+		{got: fields["request_body"], want: "payl...(truncated)"},
+		{got: fields["response_body"], want: "xxxx...(truncated)"},
+		{got: fields["conn_reused"], want: false},
+	}
+
+	for i, tt := range testcases {
+		if tt.got != tt.want {
+			t.Errorf("#%d got %v, want %v", i, tt.got, tt.want)
+		}
+	}
+}
+
+// TestDebugStream verifies streamed responses are returned before their body is read
+// and logged once closed.
+func TestDebugStream(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logged := make(chan log.Fields, 1)
+	client.EnableDebug(WithDebugLogger(func(f log.Fields) {
+		logged <- f
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := client.Request(ctx, http.MethodGet, "events", nil, WithQueryParam("access_token", "secret"), WithQueryParam("topic", "orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := make([]byte, 9)
+	if _, err := io.ReadFull(res.Body, b); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-logged:
+		t.Fatalf("got request logged before body was closed")
+	default:
+	}
+	res.Body.Close()
+
+	fields := <-logged
+	if got, want := fields["response_body"], "data: 1\n\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := fields["url"], srv.URL+"/events?access_token=REDACTED&topic=orders"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"net/http"
+	"sync"
+)
+
+// ErrorResponse is the body of error responses rendered by the default ErrorRenderer.
+type ErrorResponse struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+// ErrorRenderer renders err along with status code into w.
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, status int, err error)
+
+var (
+	errorRenderer   ErrorRenderer = renderJSONError // shared renderer used by middlewares
+	errorRendererMu sync.RWMutex                    // guard errorRenderer
+)
+
+// SetErrorRenderer replaces ErrorRenderer shared by all package middlewares and handlers.
+func SetErrorRenderer(fn ErrorRenderer) {
+	errorRendererMu.Lock()
+	errorRenderer = fn
+	errorRendererMu.Unlock()
+}
+
+// RenderError renders err along with status code into w using shared ErrorRenderer.
+func RenderError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	errorRendererMu.RLock()
+	fn := errorRenderer
+	errorRendererMu.RUnlock()
+
+	fn(w, r, status, err)
+}
+
+// renderJSONError is the default ErrorRenderer writing ErrorResponse as JSON.
+func renderJSONError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	msg := http.StatusText(status)
+	if err != nil {
+		msg = err.Error()
+	}
+	WriteJSON(w, status, ErrorResponse{Status: status, Error: msg})
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// ETag returns strong entity tag of body.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// CheckETag sets ETag response header and reports whether request If-None-Match
+// header matches etag, in which case 304 response is written and caller must not
+// write response body.
+func CheckETag(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if !matchETag(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// matchETag reports whether If-None-Match header value matches etag using weak comparison.
+func matchETag(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Conditional returns middleware computing ETag of successful GET and HEAD responses
+// and answering requests with matching If-None-Match by 304 Not Modified.
+// Responses are buffered, handlers flushing the response are streamed unchanged.
+func Conditional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferingWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+
+		if bw.streaming {
+			return
+		}
+
+		if bw.Status() == http.StatusOK {
+			etag := w.Header().Get("ETag")
+			if etag == "" {
+				etag = ETag(bw.body.Bytes())
+			}
+			if CheckETag(w, r, etag) {
+				return
+			}
+		}
+
+		w.WriteHeader(bw.Status())
+		w.Write(bw.body.Bytes())
+	})
+}
+
+// bufferingWriter is http.ResponseWriter buffering response until it is flushed.
+type bufferingWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	streaming bool // whether response was flushed and is written through
+}
+
+// Status returns response status code.
+func (w *bufferingWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *bufferingWriter) WriteHeader(status int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write implements http.ResponseWriter.
+func (w *bufferingWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+// Flush implements http.Flusher, it switches writer into streaming mode.
+func (w *bufferingWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		w.ResponseWriter.WriteHeader(w.Status())
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns underlying http.ResponseWriter, it is used by http.ResponseController.
+func (w *bufferingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
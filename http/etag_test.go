@@ -0,0 +1,84 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestMatchETag verifies If-None-Match matching.
+func TestMatchETag(t *testing.T) {
+	etag := ETag([]byte("body"))
+
+	var testcases = []struct {
+		header string
+		etag   string
+		want   bool
+	}{
+		{header: "", etag: etag, want: false},
+		{header: etag, etag: etag, want: true},
+		{header: "*", etag: etag, want: true},
+		{header: `"other", ` + etag, etag: etag, want: true},
+		{header: "W/" + etag, etag: etag, want: true},
+		{header: etag, etag: "W/" + etag, want: true},
+		{header: `"other"`, etag: etag, want: false},
+	}
+
+	for i, tt := range testcases {
+		if got := matchETag(tt.header, tt.etag); got != tt.want {
+			t.Errorf("#%d got %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+// TestConditional verifies successful GET responses are answered by 304 when entity tag matches.
+func TestConditional(t *testing.T) {
+	body := "body"
+	etag := ETag([]byte(body))
+
+	var testcases = []struct {
+		method      string
+		ifNoneMatch string
+		status      int
+		flush       bool
+
+		wantStatus int
+		wantETag   string
+		wantBody   string
+	}{
+		{method: http.MethodGet, status: http.StatusOK, wantStatus: http.StatusOK, wantETag: etag, wantBody: body},
+		{method: http.MethodGet, ifNoneMatch: etag, status: http.StatusOK, wantStatus: http.StatusNotModified, wantETag: etag},
+		{method: http.MethodGet, ifNoneMatch: `"other"`, status: http.StatusOK, wantStatus: http.StatusOK, wantETag: etag, wantBody: body},
+		{method: http.MethodGet, ifNoneMatch: etag, status: http.StatusNotFound, wantStatus: http.StatusNotFound, wantBody: body},
+		{method: http.MethodPost, ifNoneMatch: etag, status: http.StatusOK, wantStatus: http.StatusOK, wantBody: body},
+		{method: http.MethodGet, ifNoneMatch: etag, status: http.StatusOK, flush: true, wantStatus: http.StatusOK, wantBody: body}, // streamed
+	}
+
+	for i, tt := range testcases {
+		handler := Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			io.WriteString(w, body)
+			if tt.flush {
+				w.(http.Flusher).Flush()
+			}
+		}))
+
+		r := httptest.NewRequest(tt.method, "/", nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got, want := w.Code, tt.wantStatus; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := w.Header().Get("ETag"), tt.wantETag; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := w.Body.String(), tt.wantBody; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
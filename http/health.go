@@ -0,0 +1,267 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+const (
+	defaultCheckTimeout = 5 * time.Second // default time limit of a single check
+	defaultCheckTTL     = time.Second     // default lifetime of a cached check result
+)
+
+// Health check statuses.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// ErrShuttingDown is reported by readiness endpoint once the app starts shutting down.
+var ErrShuttingDown = errors.New("shutting down")
+
+// Checker is any type capable to report health of a dependency.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to allow the use of ordinary functions as Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckOption is modifier of a health check.
+type CheckOption interface {
+	apply(*check)
+}
+
+// newCheckOption constructs a new checkOption.
+func newCheckOption(fn func(c *check)) *checkOption {
+	return &checkOption{applyFn: fn}
+}
+
+// checkOption is an implementation of CheckOption.
+type checkOption struct {
+	applyFn func(c *check)
+}
+
+// apply implements CheckOption.
+func (o *checkOption) apply(c *check) {
+	o.applyFn(c)
+}
+
+// WithCheckTimeout constructs CheckOption limiting the time a single check is allowed to run.
+func WithCheckTimeout(d time.Duration) CheckOption {
+	return newCheckOption(func(c *check) {
+		c.timeout = d
+	})
+}
+
+// WithCheckCache constructs CheckOption to cache check result for ttl.
+// Zero ttl disables caching and the check is run on every request.
+func WithCheckCache(ttl time.Duration) CheckOption {
+	return newCheckOption(func(c *check) {
+		c.ttl = ttl
+	})
+}
+
+// CheckResult represents outcome of a single health check.
+type CheckResult struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// HealthReport represents outcome of a set of health checks.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// check is a named Checker along with its settings and cached result.
+type check struct {
+	name    string
+	checker Checker
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu      sync.Mutex // guard fields below
+	result  CheckResult
+	expires time.Time
+	call    *checkCall // in-flight run, nil if none
+}
+
+// checkCall is an in-flight run of a check shared by concurrent probes.
+type checkCall struct {
+	done   chan struct{} // closed once result is set
+	result CheckResult
+}
+
+// newCheck constructs a new check applying given options.
+func newCheck(name string, c Checker, opts ...CheckOption) *check {
+	chk := &check{
+		name:    name,
+		checker: c,
+		timeout: defaultCheckTimeout,
+		ttl:     defaultCheckTTL,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(chk)
+		}
+	}
+	return chk
+}
+
+// run returns cached result if such is still valid, otherwise it runs the check.
+// Concurrent probes share a single run, a probe giving up due to ctx being done does
+// not affect the run nor its cached result.
+func (c *check) run(ctx context.Context) CheckResult {
+	now := time.Now()
+
+	c.mu.Lock()
+	if now.Before(c.expires) {
+		result := c.result
+		c.mu.Unlock()
+		return result
+	}
+	call := c.call
+	if call == nil {
+		call = &checkCall{done: make(chan struct{})}
+		c.call = call
+		go c.do(context.WithoutCancel(ctx), call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.result
+	case <-ctx.Done():
+		return CheckResult{
+			Status:    StatusFail,
+			Error:     ctx.Err().Error(),
+			Duration:  time.Since(now).String(),
+			CheckedAt: now.UTC(),
+		}
+	}
+}
+
+// do runs the checker and caches its result.
+func (c *check) do(ctx context.Context, call *checkCall) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	now := time.Now()
+	result := CheckResult{
+		Status:    StatusOK,
+		CheckedAt: now.UTC(),
+	}
+	if err := c.checker.Check(ctx); err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	result.Duration = time.Since(now).String()
+
+	c.mu.Lock()
+	c.result, c.expires = result, now.Add(c.ttl)
+	c.call = nil
+	c.mu.Unlock()
+
+	call.result = result
+	close(call.done)
+}
+
+// health holds registered liveness and readiness checks.
+type health struct {
+	mu        sync.RWMutex // guard fields below
+	liveness  []*check
+	readiness []*check
+
+	shuttingDown atomic.Bool
+}
+
+// run runs all checks concurrently and builds a report out of their results.
+func (h *health) run(ctx context.Context, checks []*check) HealthReport {
+	report := HealthReport{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c *check) {
+			defer wg.Done()
+			result := c.run(ctx)
+
+			mu.Lock()
+			report.Checks[c.name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// liveHandler serves liveness report.
+func (h *health) liveHandler(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	checks := h.liveness
+	h.mu.RUnlock()
+
+	h.write(w, h.run(r.Context(), checks))
+}
+
+// readyHandler serves readiness report.
+// Readiness fails as soon as the app starts shutting down.
+func (h *health) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		h.write(w, HealthReport{Status: StatusFail, Error: ErrShuttingDown.Error()})
+		return
+	}
+
+	h.mu.RLock()
+	checks := h.readiness
+	h.mu.RUnlock()
+
+	h.write(w, h.run(r.Context(), checks))
+}
+
+// write writes report to w.
+func (h *health) write(w http.ResponseWriter, report HealthReport) {
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	WriteJSON(w, status, report)
+}
+
+// Checker returns Checker reporting whether HTTP dependency responds successfully to GET uri.
+func (c *Client) Checker(uri string, options ...RequestOption) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		res, err := c.Request(ctx, http.MethodGet, uri, nil, options...)
+		if err != nil {
+			return err
+		}
+		return res.Body.Close()
+	})
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// TestHealth verifies liveness and readiness endpoints behavior.
+func TestHealth(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+
+	var calls int
+	app.AddLivenessCheck("ok", CheckerFunc(func(ctx context.Context) error {
+		calls++
+		return nil
+	}))
+	app.AddReadinessCheck("fail", CheckerFunc(func(ctx context.Context) error {
+		return errors.New("unavailable")
+	}), WithCheckCache(0))
+
+	var testcases = []struct {
+		path     string
+		shutdown bool
+
+		status int
+		report HealthReport
+	}{
+		{
+			path:   LivenessPath,
+			status: http.StatusOK,
+			report: HealthReport{Status: StatusOK},
+		},
+		{
+			path:   ReadinessPath,
+			status: http.StatusServiceUnavailable,
+			report: HealthReport{Status: StatusFail},
+		},
+		{
+			path:     ReadinessPath,
+			shutdown: true,
+			status:   http.StatusServiceUnavailable,
+			report:   HealthReport{Status: StatusFail, Error: ErrShuttingDown.Error()},
+		},
+	}
+
+	for i, tt := range testcases {
+		if tt.shutdown {
+			app.SignalShutdown()
+		}
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("#%d got %v, want %v", i, w.Code, tt.status)
+		}
+
+		var report HealthReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("#%d got %v, want %v", i, err, nil)
+		}
+
+		if report.Status != tt.report.Status || report.Error != tt.report.Error {
+			t.Errorf("#%d got %v, want %v", i, report, tt.report)
+		}
+	}
+
+	// liveness check result should be served from cache
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	if want := 1; calls != want {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+}
+
+// TestCheckConcurrent verifies concurrent probes share a single run of a slow check.
+func TestCheckConcurrent(t *testing.T) {
+	var (
+		calls   atomic.Int32
+		release = make(chan struct{})
+	)
+	chk := newCheck("slow", CheckerFunc(func(ctx context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}))
+
+	var wg sync.WaitGroup
+	results := make([]CheckResult, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = chk.run(context.Background())
+		}(i)
+	}
+
+	// wait for the run to start before releasing it
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got, want := calls.Load(), int32(1); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for i, result := range results {
+		if got, want := result.Status, StatusOK; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestCheckCancelled verifies probe giving up does not cache a failure.
+func TestCheckCancelled(t *testing.T) {
+	release := make(chan struct{})
+	chk := newCheck("slow", CheckerFunc(func(ctx context.Context) error {
+		<-release
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var testcases = []struct {
+		ctx     context.Context
+		release bool
+		status  string
+	}{
+		{ctx: ctx, status: StatusFail},
+		{ctx: context.Background(), release: true, status: StatusOK},
+		{ctx: ctx, status: StatusOK}, // served from cache
+	}
+
+	for i, tt := range testcases {
+		if tt.release {
+			close(release)
+		}
+		if got, want := chk.run(tt.ctx).Status, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
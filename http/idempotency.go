@@ -0,0 +1,322 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/idempotency"
+	"github.com/deividaspetraitis/go/log"
+)
+
+// Idempotency related headers.
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	defaultIdempotencyRetention = 24 * time.Hour        // default lifetime of stored responses
+	defaultIdempotencyLock      = time.Minute           // default lifetime of in-progress records
+	defaultIdempotencyMaxBody   = 1 << 20               // default limit of fingerprinted request body size
+	idempotencyPollInterval     = 50 * time.Millisecond // interval of polling for in-progress records
+)
+
+var (
+	// ErrIdempotencyKeyInUse is rendered when request with the same key is still in progress.
+	ErrIdempotencyKeyInUse = errors.New("request with the same idempotency key is in progress")
+
+	// ErrIdempotencyKeyReused is rendered when key is reused for a different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key is reused for a different request")
+)
+
+// IdempotencyOption is modifier of an idempotency middleware.
+type IdempotencyOption interface {
+	apply(*idempotent)
+}
+
+// newIdempotencyOption constructs a new idempotencyOption.
+func newIdempotencyOption(fn func(i *idempotent)) *idempotencyOption {
+	return &idempotencyOption{applyFn: fn}
+}
+
+// idempotencyOption is an implementation of IdempotencyOption.
+type idempotencyOption struct {
+	applyFn func(i *idempotent)
+}
+
+// apply implements IdempotencyOption.
+func (o *idempotencyOption) apply(i *idempotent) {
+	o.applyFn(i)
+}
+
+// WithIdempotencyStore constructs IdempotencyOption to persist records in store.
+func WithIdempotencyStore(store idempotency.Store) IdempotencyOption {
+	return newIdempotencyOption(func(i *idempotent) {
+		i.store = store
+	})
+}
+
+// WithIdempotencyRetention constructs IdempotencyOption setting for how long responses are replayed.
+func WithIdempotencyRetention(ttl time.Duration) IdempotencyOption {
+	return newIdempotencyOption(func(i *idempotent) {
+		i.retention = ttl
+	})
+}
+
+// WithIdempotencyLock constructs IdempotencyOption setting for how long in-progress request holds its key.
+// It bounds the time key stays blocked if the process handling request dies.
+func WithIdempotencyLock(ttl time.Duration) IdempotencyOption {
+	return newIdempotencyOption(func(i *idempotent) {
+		i.lock = ttl
+	})
+}
+
+// WithIdempotencyWait constructs IdempotencyOption to make concurrent duplicates wait
+// up to timeout for the original request to complete instead of failing immediately.
+func WithIdempotencyWait(timeout time.Duration) IdempotencyOption {
+	return newIdempotencyOption(func(i *idempotent) {
+		i.wait = timeout
+	})
+}
+
+// WithIdempotencyMaxBody constructs IdempotencyOption limiting size of request body read to fingerprint request.
+// Requests carrying larger bodies are rejected with 413 response.
+func WithIdempotencyMaxBody(n int64) IdempotencyOption {
+	return newIdempotencyOption(func(i *idempotent) {
+		i.maxBody = n
+	})
+}
+
+// idempotent holds idempotency middleware settings.
+type idempotent struct {
+	store     idempotency.Store
+	retention time.Duration
+	lock      time.Duration
+	wait      time.Duration
+	maxBody   int64
+}
+
+// Idempotency returns middleware making mutating requests carrying Idempotency-Key safe to retry.
+// The first response is stored and replayed to requests with the same key and fingerprint,
+// concurrent duplicates either wait for the original request or receive 409 response.
+// Server errors are not stored, so requests failing with 5xx can be retried.
+func Idempotency(opts ...IdempotencyOption) func(http.Handler) http.Handler {
+	i := &idempotent{
+		retention: defaultIdempotencyRetention,
+		lock:      defaultIdempotencyLock,
+		maxBody:   defaultIdempotencyMaxBody,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(i)
+		}
+	}
+	if i.store == nil {
+		i.store = idempotency.NewMemoryStore()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !mutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// keys are scoped by authenticated subject
+			if subject, ok := SubjectFromContext(r.Context()); ok {
+				key = subject + ":" + key
+			}
+
+			fingerprint, err := fingerprintRequest(w, r, i.maxBody)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				RenderError(w, r, http.StatusRequestEntityTooLarge, err)
+				return
+			}
+			if err != nil {
+				RenderError(w, r, http.StatusBadRequest, err)
+				return
+			}
+
+			rec, created, err := i.store.Begin(r.Context(), key, fingerprint, i.lock)
+			if err != nil {
+				log.WithError(err).Error("idempotency: unable to begin request")
+				RenderError(w, r, http.StatusInternalServerError, nil)
+				return
+			}
+
+			if !created {
+				i.replay(w, r, rec, fingerprint)
+				return
+			}
+
+			i.serve(next, w, r, key)
+		})
+	}
+}
+
+// serve serves the original request and stores its response.
+func (i *idempotent) serve(next http.Handler, w http.ResponseWriter, r *http.Request, key string) {
+	// release key if handler panics, so that request can be retried
+	completed := false
+	defer func() {
+		if !completed {
+			i.store.Release(context.WithoutCancel(r.Context()), key)
+		}
+	}()
+
+	rw := &recordingWriter{ResponseWriter: w}
+	next.ServeHTTP(rw, r)
+
+	ctx := context.WithoutCancel(r.Context())
+	if rw.Status() >= http.StatusInternalServerError {
+		return
+	}
+	if rw.header == nil {
+		rw.header = w.Header().Clone()
+	}
+
+	if err := i.store.Complete(ctx, key, &idempotency.Response{
+		Status: rw.Status(),
+		Header: rw.header,
+		Body:   rw.body.Bytes(),
+	}, i.retention); err != nil {
+		log.WithError(err).Error("idempotency: unable to store response")
+		return
+	}
+	completed = true
+}
+
+// replay replays stored response of rec or waits for in-progress request to complete.
+func (i *idempotent) replay(w http.ResponseWriter, r *http.Request, rec *idempotency.Record, fingerprint string) {
+	if rec.Fingerprint != fingerprint {
+		RenderError(w, r, http.StatusUnprocessableEntity, ErrIdempotencyKeyReused)
+		return
+	}
+
+	if rec.Response == nil && i.wait > 0 {
+		var err error
+		if rec, err = i.await(r.Context(), rec.Key); err != nil {
+			if errors.Is(err, idempotency.ErrRecordNotFound) {
+				// original request failed and released the key, client may retry
+				RenderError(w, r, http.StatusConflict, ErrIdempotencyKeyInUse)
+				return
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				log.WithError(err).Error("idempotency: unable to wait for request")
+			}
+		}
+	}
+
+	if rec == nil || rec.Response == nil {
+		RenderError(w, r, http.StatusConflict, ErrIdempotencyKeyInUse)
+		return
+	}
+
+	for k, v := range rec.Response.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(IdempotencyReplayedHeader, "true")
+	w.WriteHeader(rec.Response.Status)
+	w.Write(rec.Response.Body)
+}
+
+// await polls store until record identified by key is completed or wait timeout elapses.
+func (i *idempotent) await(ctx context.Context, key string) (*idempotency.Record, error) {
+	ctx, cancel := context.WithTimeout(ctx, i.wait)
+	defer cancel()
+
+	ticker := time.NewTicker(idempotencyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		rec, err := i.store.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if rec.Response != nil {
+			return rec, nil
+		}
+	}
+}
+
+// mutating reports whether HTTP method is not safe.
+func mutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return true
+}
+
+// fingerprintRequest returns fingerprint of request method, URL and body of at most limit bytes.
+// Request body is restored so it can be read again.
+func fingerprintRequest(w http.ResponseWriter, r *http.Request, limit int64) (string, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, limit)); err != nil {
+			return "", errors.Wrap(err, "reading request body")
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	h := sha256.New()
+	io.WriteString(h, r.Method+"\n"+r.URL.Path+"\n"+r.URL.RawQuery+"\n")
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// recordingWriter is http.ResponseWriter capturing response while writing it through.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// Status returns response status code.
+func (w *recordingWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns underlying http.ResponseWriter, it is used by http.ResponseController.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
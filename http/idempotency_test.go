@@ -0,0 +1,138 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/idempotency"
+)
+
+// TestIdempotency verifies responses are replayed to retried requests.
+func TestIdempotency(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := idempotency.NewMemoryStore()
+	store.Now = func() time.Time { return now }
+
+	var calls int
+	handler := Idempotency(WithIdempotencyStore(store), WithIdempotencyMaxBody(16))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s %d", body, calls)
+	}))
+
+	var testcases = []struct {
+		method  string
+		key     string
+		body    string
+		elapsed time.Duration
+
+		status   int
+		response string
+		replayed bool
+		calls    int
+	}{
+		{method: http.MethodPost, key: "a", body: "x", status: http.StatusCreated, response: "x 1", calls: 1},
+		{method: http.MethodPost, key: "a", body: "x", status: http.StatusCreated, response: "x 1", replayed: true, calls: 1},
+		{method: http.MethodPost, key: "a", body: "y", status: http.StatusUnprocessableEntity, calls: 1}, // fingerprint mismatch
+		{method: http.MethodPost, key: "", body: "x", status: http.StatusCreated, response: "x 2", calls: 2},
+		{method: http.MethodGet, key: "a", body: "", status: http.StatusCreated, response: " 3", calls: 3},
+		{method: http.MethodPost, key: "b", body: "fail", status: http.StatusInternalServerError, calls: 4},
+		{method: http.MethodPost, key: "b", body: "fail", status: http.StatusInternalServerError, calls: 5}, // server errors are not stored
+		{method: http.MethodPost, key: "c", body: strings.Repeat("x", 17), status: http.StatusRequestEntityTooLarge, calls: 5},
+		{method: http.MethodPost, key: "a", body: "x", elapsed: 25 * time.Hour, status: http.StatusCreated, response: "x 6", calls: 6}, // expired
+	}
+
+	for i, tt := range testcases {
+		now = now.Add(tt.elapsed)
+
+		r := httptest.NewRequest(tt.method, "/orders", strings.NewReader(tt.body))
+		if tt.key != "" {
+			r.Header.Set(IdempotencyKeyHeader, tt.key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := w.Header().Get(IdempotencyReplayedHeader) == "true", tt.replayed; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if tt.response != "" && w.Body.String() != tt.response {
+			t.Errorf("#%d got %v, want %v", i, w.Body.String(), tt.response)
+		}
+		if calls != tt.calls {
+			t.Errorf("#%d got %v, want %v", i, calls, tt.calls)
+		}
+	}
+}
+
+// TestIdempotencyInFlight verifies concurrent duplicates are rejected or wait for the original request.
+func TestIdempotencyInFlight(t *testing.T) {
+	var testcases = []struct {
+		wait time.Duration
+
+		status   int
+		replayed bool
+	}{
+		{wait: 0, status: http.StatusConflict},
+		{wait: 5 * time.Second, status: http.StatusCreated, replayed: true},
+	}
+
+	for i, tt := range testcases {
+		var (
+			entered = make(chan struct{})
+			release = make(chan struct{})
+		)
+		handler := Idempotency(WithIdempotencyWait(tt.wait))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		request := func() *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			r.Header.Set(IdempotencyKeyHeader, "a")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			return w
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, want := request().Code, http.StatusCreated; got != want {
+				t.Errorf("#%d got %v, want %v", i, got, want)
+			}
+		}()
+		<-entered
+
+		if tt.wait > 0 {
+			time.AfterFunc(100*time.Millisecond, func() { close(release) })
+		}
+		w := request()
+		if tt.wait == 0 {
+			close(release)
+		}
+		wg.Wait()
+
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := w.Header().Get(IdempotencyReplayedHeader) == "true", tt.replayed; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
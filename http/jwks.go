@@ -0,0 +1,286 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/log"
+)
+
+const (
+	defaultJWKSTTL     = time.Hour        // default lifetime of a cached key set
+	defaultJWKSRefresh = 30 * time.Second // minimum interval between forced key set refreshes
+)
+
+// curveAlgorithms maps supported elliptic curves to signing algorithm using them.
+var curveAlgorithms = map[string]string{
+	"P-256": ES256,
+}
+
+// JWK represents a single JSON Web Key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// Key returns verification key represented by JWK.
+func (k *JWK) Key() (any, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch k.Kty {
+	case "oct":
+		return decode(k.K)
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		alg, ok := curveAlgorithms[k.Crv]
+		if !ok {
+			return nil, errors.Newf("unsupported curve %q", k.Crv)
+		}
+		if k.Alg != "" && k.Alg != alg {
+			return nil, errors.Newf("algorithm %q does not match curve %q", k.Alg, k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	}
+
+	return nil, errors.Newf("unsupported key type %q", k.Kty)
+}
+
+// JWKSOption is modifier of a JWKS.
+type JWKSOption interface {
+	apply(*JWKS)
+}
+
+// newJWKSOption constructs a new jwksOption.
+func newJWKSOption(fn func(s *JWKS)) *jwksOption {
+	return &jwksOption{applyFn: fn}
+}
+
+// jwksOption is an implementation of JWKSOption.
+type jwksOption struct {
+	applyFn func(s *JWKS)
+}
+
+// apply implements JWKSOption.
+func (o *jwksOption) apply(s *JWKS) {
+	o.applyFn(s)
+}
+
+// WithJWKSCache constructs JWKSOption setting for how long loaded key set is cached.
+func WithJWKSCache(ttl time.Duration) JWKSOption {
+	return newJWKSOption(func(s *JWKS) {
+		s.ttl = ttl
+	})
+}
+
+// JWKS is KeySet backed by JSON Web Key Set document.
+// Loaded keys are cached, unknown key IDs force reload at most once per refresh interval.
+// Keys are served from cache while key set can not be reloaded, failed loads are retried
+// at most once per refresh interval.
+type JWKS struct {
+	load    func(ctx context.Context) ([]byte, error)
+	ttl     time.Duration
+	refresh time.Duration
+
+	mu      sync.Mutex // guard fields below
+	keys    map[string]jwksKey
+	expires time.Time
+	loaded  time.Time
+	err     error     // last load error, set while there are no keys to serve
+	call    *jwksCall // in-flight reload, nil if none
+
+	// Returns the current time. Defaults to time.Now()
+	// Can be mocked for tests
+	Now func() time.Time
+}
+
+// jwksKey is a parsed JWK.
+type jwksKey struct {
+	alg string
+	key any
+}
+
+// jwksCall is a reload shared by concurrent callers.
+type jwksCall struct {
+	done chan struct{} // closed once reload completes
+	err  error
+}
+
+// NewJWKS constructs and returns new JWKS instance loading documents by load.
+func NewJWKS(load func(ctx context.Context) ([]byte, error), opts ...JWKSOption) *JWKS {
+	s := &JWKS{
+		load:    load,
+		ttl:     defaultJWKSTTL,
+		refresh: defaultJWKSRefresh,
+		Now:     time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(s)
+		}
+	}
+	return s
+}
+
+// NewJWKSFile constructs and returns new JWKS instance loading document from file.
+func NewJWKSFile(path string, opts ...JWKSOption) *JWKS {
+	return NewJWKS(func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}, opts...)
+}
+
+// NewJWKSEndpoint constructs and returns new JWKS instance loading document from uri using client.
+func NewJWKSEndpoint(client *Client, uri string, opts ...JWKSOption) *JWKS {
+	return NewJWKS(func(ctx context.Context) ([]byte, error) {
+		res, err := client.Request(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		return io.ReadAll(res.Body)
+	}, opts...)
+}
+
+// Key implements KeySet.
+func (s *JWKS) Key(ctx context.Context, kid, alg string) (any, error) {
+	now := s.Now()
+
+	s.mu.Lock()
+	k, ok := s.keys[kid]
+	// reload expired key set, unknown key might have been rotated in
+	stale := now.After(s.expires) || (!ok && now.Sub(s.loaded) >= s.refresh)
+	err := s.err
+	s.mu.Unlock()
+
+	if !stale && err != nil {
+		return nil, err // failed load is not retried within refresh interval
+	}
+	if stale {
+		if err := s.reload(ctx, now); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		k, ok = s.keys[kid]
+		s.mu.Unlock()
+	}
+
+	if !ok || (k.alg != "" && k.alg != alg) {
+		return nil, ErrKeyNotFound
+	}
+
+	return k.key, nil
+}
+
+// reload reloads key set, concurrent callers share a single load which runs without holding s.mu.
+// Failed reload keeps previously loaded keys, it is retried once refresh interval elapses.
+// Error is returned only if there are no keys to serve, it is remembered until the retry.
+func (s *JWKS) reload(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	if call := s.call; call != nil {
+		s.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &jwksCall{done: make(chan struct{})}
+	s.call = call
+	s.mu.Unlock()
+
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	switch {
+	case err == nil:
+		s.keys, s.expires, s.err = keys, now.Add(s.ttl), nil
+	case s.keys != nil:
+		log.WithError(err).Error("jwks: serving cached keys")
+		s.expires, err = now.Add(s.refresh), nil
+	default:
+		s.expires, s.err = now.Add(s.refresh), err
+	}
+	s.loaded, s.call = now, nil
+	s.mu.Unlock()
+
+	call.err = err
+	close(call.done)
+
+	return err
+}
+
+// fetch loads and parses key set document.
+func (s *JWKS) fetch(ctx context.Context) (map[string]jwksKey, error) {
+	b, err := s.load(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "jwks: load key set")
+	}
+
+	var doc struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, errors.Wrap(err, "jwks: decode key set")
+	}
+
+	keys := make(map[string]jwksKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.Key()
+		if err != nil {
+			continue // skip keys we can not use
+		}
+		alg := jwk.Alg
+		if alg == "" {
+			alg = curveAlgorithms[jwk.Crv] // EC keys are usable only with algorithm of their curve
+		}
+		keys[jwk.Kid] = jwksKey{alg: alg, key: key}
+	}
+
+	return keys, nil
+}
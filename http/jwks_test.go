@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// jwksDocument returns key set document of HS256 keys identified by kids, it is a test helper.
+func jwksDocument(kids ...string) []byte {
+	var keys []string
+	for _, kid := range kids {
+		k := base64.RawURLEncoding.EncodeToString([]byte("secret-" + kid))
+		keys = append(keys, fmt.Sprintf(`{"kty":"oct","kid":%q,"alg":"HS256","k":%q}`, kid, k))
+	}
+	return []byte(fmt.Sprintf(`{"keys":[%s]}`, strings.Join(keys, ",")))
+}
+
+// TestJWKS verifies key set caching, rotation and serving cached keys when reload fails.
+func TestJWKS(t *testing.T) {
+	var (
+		doc   []byte
+		fail  error
+		calls int
+	)
+	s := NewJWKS(func(ctx context.Context) ([]byte, error) {
+		calls++
+		return doc, fail
+	}, WithJWKSCache(time.Hour))
+
+	start := time.Unix(1000, 0)
+
+	var testcases = []struct {
+		elapsed time.Duration
+		doc     []byte
+		fail    error
+		kid     string
+		alg     string
+
+		calls int
+		err   error
+	}{
+		{doc: jwksDocument("a"), kid: "a", alg: HS256, calls: 1},
+		{elapsed: time.Second, doc: jwksDocument("a", "b"), kid: "a", alg: HS256, calls: 1},                          // cached
+		{elapsed: time.Second, doc: jwksDocument("a", "b"), kid: "a", alg: RS256, calls: 1, err: ErrKeyNotFound},     // algorithm mismatch
+		{elapsed: 2 * time.Second, doc: jwksDocument("a", "b"), kid: "b", alg: HS256, calls: 1, err: ErrKeyNotFound}, // refresh interval not elapsed
+		{elapsed: time.Minute, doc: jwksDocument("a", "b"), kid: "b", alg: HS256, calls: 2},                          // rotated in
+		{elapsed: 2 * time.Hour, fail: errors.New("unavailable"), kid: "b", alg: HS256, calls: 3},                    // expired, served from cache
+		{elapsed: 2 * time.Hour, fail: errors.New("unavailable"), kid: "a", alg: HS256, calls: 3},                    // not retried within refresh interval
+		{elapsed: 2*time.Hour + time.Minute, doc: jwksDocument("c"), kid: "a", alg: HS256, calls: 4, err: ErrKeyNotFound},
+	}
+
+	for i, tt := range testcases {
+		doc, fail = tt.doc, tt.fail
+		s.Now = func() time.Time { return start.Add(tt.elapsed) }
+
+		_, err := s.Key(context.Background(), tt.kid, tt.alg)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if calls != tt.calls {
+			t.Errorf("#%d got %v, want %v", i, calls, tt.calls)
+		}
+	}
+}
+
+// TestJWKSUnavailable verifies load error is returned when there are no cached keys
+// and failed load is retried at most once per refresh interval.
+func TestJWKSUnavailable(t *testing.T) {
+	var calls int
+	s := NewJWKS(func(ctx context.Context) ([]byte, error) {
+		calls++
+		return nil, errors.New("unavailable")
+	})
+
+	start := time.Unix(1000, 0)
+
+	var testcases = []struct {
+		elapsed time.Duration
+		calls   int
+	}{
+		{elapsed: 0, calls: 1},
+		{elapsed: time.Second, calls: 1},
+		{elapsed: 29 * time.Second, calls: 1},
+		{elapsed: time.Minute, calls: 2},
+	}
+
+	for i, tt := range testcases {
+		s.Now = func() time.Time { return start.Add(tt.elapsed) }
+
+		if _, err := s.Key(context.Background(), "a", HS256); err == nil || errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("#%d got %v, want %v", i, err, "load error")
+		}
+		if calls != tt.calls {
+			t.Errorf("#%d got %v, want %v", i, calls, tt.calls)
+		}
+	}
+}
+
+// TestJWKCurve verifies EC keys are usable only with algorithm of their curve.
+func TestJWKCurve(t *testing.T) {
+	xy := base64.RawURLEncoding.EncodeToString(make([]byte, 32))
+
+	var testcases = []struct {
+		crv string
+		alg string
+		use string
+
+		err error
+	}{
+		{crv: "P-256", alg: "", use: ES256},
+		{crv: "P-256", alg: ES256, use: ES256},
+		{crv: "P-256", alg: "", use: "ES384", err: ErrKeyNotFound},
+		{crv: "P-256", alg: "ES384", use: "ES384", err: ErrKeyNotFound},
+		{crv: "P-384", alg: "ES384", use: "ES384", err: ErrKeyNotFound},
+	}
+
+	for i, tt := range testcases {
+		doc := fmt.Sprintf(`{"keys":[{"kty":"EC","kid":"a","alg":%q,"crv":%q,"x":%q,"y":%q}]}`, tt.alg, tt.crv, xy, xy)
+		s := NewJWKS(func(ctx context.Context) ([]byte, error) {
+			return []byte(doc), nil
+		})
+
+		if _, err := s.Key(context.Background(), "a", tt.use); !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// TestJWKSConcurrent verifies concurrent callers share a single load.
+func TestJWKSConcurrent(t *testing.T) {
+	var (
+		calls   int32
+		release = make(chan struct{})
+	)
+	s := NewJWKS(func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return jwksDocument("a"), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Key(context.Background(), "a", HS256); err != nil {
+				t.Errorf("got %v, want %v", err, nil)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
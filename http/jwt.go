@@ -0,0 +1,363 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+
+	"golang.org/x/exp/slices"
+)
+
+// Supported JWT signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotValidYet = errors.New("token not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrKeyNotFound      = errors.New("signing key not found")
+)
+
+// Claims represents JWT claims set.
+type Claims map[string]any
+
+// Subject returns sub claim.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Issuer returns iss claim.
+func (c Claims) Issuer() string {
+	s, _ := c["iss"].(string)
+	return s
+}
+
+// Audience returns aud claim, it might be either a string or list of strings.
+func (c Claims) Audience() []string {
+	return c.strings("aud")
+}
+
+// ExpiresAt returns exp claim.
+func (c Claims) ExpiresAt() (time.Time, bool) {
+	return c.time("exp")
+}
+
+// NotBefore returns nbf claim.
+func (c Claims) NotBefore() (time.Time, bool) {
+	return c.time("nbf")
+}
+
+// time returns NumericDate claim by its name.
+func (c Claims) time(name string) (time.Time, bool) {
+	v, ok := c[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := v.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(f*float64(time.Second))), true
+}
+
+// strings returns claim by its name being either a string or list of strings.
+// Space delimited string is split into a list, ie. OAuth 2 scope claim.
+func (c Claims) strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		var s []string
+		for _, e := range v {
+			if e, ok := e.(string); ok {
+				s = append(s, e)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
+// KeySet is any type capable to resolve JWT signing keys.
+type KeySet interface {
+	// Key returns verification key identified by kid for given algorithm.
+	// Returned key is []byte for HS256, *rsa.PublicKey for RS256 and *ecdsa.PublicKey for ES256.
+	Key(ctx context.Context, kid, alg string) (any, error)
+}
+
+// KeySetFunc is an adapter to allow the use of ordinary functions as KeySet.
+type KeySetFunc func(ctx context.Context, kid, alg string) (any, error)
+
+// Key implements KeySet.
+func (f KeySetFunc) Key(ctx context.Context, kid, alg string) (any, error) {
+	return f(ctx, kid, alg)
+}
+
+// StaticKey returns KeySet resolving key regardless of key ID.
+func StaticKey(key any) KeySet {
+	return KeySetFunc(func(ctx context.Context, kid, alg string) (any, error) {
+		return key, nil
+	})
+}
+
+// JWTOption is modifier of a JWTVerifier.
+type JWTOption interface {
+	apply(*JWTVerifier)
+}
+
+// newJWTOption constructs a new jwtOption.
+func newJWTOption(fn func(v *JWTVerifier)) *jwtOption {
+	return &jwtOption{applyFn: fn}
+}
+
+// jwtOption is an implementation of JWTOption.
+type jwtOption struct {
+	applyFn func(v *JWTVerifier)
+}
+
+// apply implements JWTOption.
+func (o *jwtOption) apply(v *JWTVerifier) {
+	o.applyFn(v)
+}
+
+// WithIssuer constructs JWTOption requiring token to be issued by issuer.
+func WithIssuer(issuer string) JWTOption {
+	return newJWTOption(func(v *JWTVerifier) {
+		v.issuer = issuer
+	})
+}
+
+// WithAudience constructs JWTOption requiring token audience to contain audience.
+func WithAudience(audience string) JWTOption {
+	return newJWTOption(func(v *JWTVerifier) {
+		v.audience = audience
+	})
+}
+
+// WithLeeway constructs JWTOption allowing for clock skew while validating time based claims.
+func WithLeeway(d time.Duration) JWTOption {
+	return newJWTOption(func(v *JWTVerifier) {
+		v.leeway = d
+	})
+}
+
+// WithAlgorithms constructs JWTOption restricting accepted signing algorithms.
+func WithAlgorithms(alg ...string) JWTOption {
+	return newJWTOption(func(v *JWTVerifier) {
+		v.algorithms = alg
+	})
+}
+
+// WithRolesClaim constructs JWTOption setting claim principal roles are read from.
+func WithRolesClaim(name string) JWTOption {
+	return newJWTOption(func(v *JWTVerifier) {
+		v.rolesClaim = name
+	})
+}
+
+// JWTVerifier verifies JSON Web Tokens.
+// It implements Authenticator authenticating requests by Bearer token.
+type JWTVerifier struct {
+	keys       KeySet
+	issuer     string
+	audience   string
+	leeway     time.Duration
+	algorithms []string
+	rolesClaim string
+
+	// Returns the current time. Defaults to time.Now()
+	// Can be mocked for tests
+	Now func() time.Time
+}
+
+// NewJWTVerifier constructs and returns new JWTVerifier instance.
+func NewJWTVerifier(keys KeySet, opts ...JWTOption) *JWTVerifier {
+	v := &JWTVerifier{
+		keys:       keys,
+		algorithms: []string{HS256, RS256, ES256},
+		rolesClaim: "roles",
+		Now:        time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(v)
+		}
+	}
+	return v
+}
+
+// jwtHeader represents JOSE header.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify verifies token signature and claims and returns token claims.
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(v.algorithms, header.Alg) {
+		return nil, errors.Wrapf(ErrInvalidToken, "unsupported algorithm %q", header.Alg)
+	}
+
+	key, err := v.keys.Key(ctx, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// validate validates registered claims.
+func (v *JWTVerifier) validate(claims Claims) error {
+	now := v.Now()
+
+	if exp, ok := claims.ExpiresAt(); ok && !now.Before(exp.Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+
+	if nbf, ok := claims.NotBefore(); ok && now.Add(v.leeway).Before(nbf) {
+		return ErrTokenNotValidYet
+	}
+
+	if v.issuer != "" && claims.Issuer() != v.issuer {
+		return ErrInvalidIssuer
+	}
+
+	if v.audience != "" && !slices.Contains(claims.Audience(), v.audience) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+// Authenticate implements Authenticator.
+func (v *JWTVerifier) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := v.Verify(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := claims.strings("scope")
+	if len(scopes) == 0 {
+		scopes = claims.strings("scp")
+	}
+
+	return &Principal{
+		Subject: claims.Subject(),
+		Method:  AuthMethodJWT,
+		Roles:   claims.strings(v.rolesClaim),
+		Scopes:  scopes,
+		Claims:  claims,
+	}, nil
+}
+
+// decodeSegment decodes base64url encoded JSON segment into v.
+func decodeSegment(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// verifySignature verifies signature of signing input using key for given algorithm.
+// Key type must match the algorithm to prevent algorithm confusion.
+func verifySignature(alg string, key any, input string, signature []byte) error {
+	digest := sha256.Sum256([]byte(input))
+
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(input))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrInvalidSignature
+		}
+	case RS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidSignature
+		}
+	case ES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		if len(signature) != 64 {
+			return ErrInvalidSignature
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrInvalidSignature
+		}
+	default:
+		return errors.Wrapf(ErrInvalidToken, "unsupported algorithm %q", alg)
+	}
+
+	return nil
+}
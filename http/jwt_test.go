@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// sign builds a signed token out of claims, it is a test helper.
+func sign(t *testing.T, alg string, key any, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch alg {
+	case HS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case RS256:
+		s, err := rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = s
+	case ES256:
+		r, s, err := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// TestJWTVerifier verifies token signature and claims validation.
+func TestJWTVerifier(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	valid := map[string]any{
+		"sub": "user", "iss": "issuer", "aud": []string{"api"},
+		"exp": now.Add(time.Minute).Unix(), "roles": []string{"admin"},
+	}
+	expired := map[string]any{"sub": "user", "iss": "issuer", "aud": "api", "exp": now.Unix()}
+	audience := map[string]any{"sub": "user", "iss": "issuer", "aud": "other"}
+
+	var testcases = []struct {
+		verifyKey any
+		alg       string
+		signKey   any
+		claims    map[string]any
+
+		err error
+	}{
+		{verifyKey: secret, alg: HS256, signKey: secret, claims: valid},
+		{verifyKey: &rsaKey.PublicKey, alg: RS256, signKey: rsaKey, claims: valid},
+		{verifyKey: &ecKey.PublicKey, alg: ES256, signKey: ecKey, claims: valid},
+		{verifyKey: []byte("other"), alg: HS256, signKey: secret, claims: valid, err: ErrInvalidSignature},
+		{verifyKey: &rsaKey.PublicKey, alg: HS256, signKey: secret, claims: valid, err: ErrKeyNotFound},
+		{verifyKey: &rsaKey.PublicKey, alg: ES256, signKey: ecKey, claims: valid, err: ErrKeyNotFound},
+		{verifyKey: secret, alg: RS256, signKey: rsaKey, claims: valid, err: ErrKeyNotFound},
+		{verifyKey: secret, alg: HS256, signKey: secret, claims: expired, err: ErrTokenExpired},
+		{verifyKey: secret, alg: HS256, signKey: secret, claims: audience, err: ErrInvalidAudience},
+	}
+
+	for i, tt := range testcases {
+		v := NewJWTVerifier(StaticKey(tt.verifyKey), WithIssuer("issuer"), WithAudience("api"))
+		v.Now = func() time.Time { return now }
+
+		claims, err := v.Verify(context.Background(), sign(t, tt.alg, tt.signKey, tt.claims))
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("#%d got %v, want %v", i, err, tt.err)
+		}
+
+		if err == nil && claims.Subject() != "user" {
+			t.Errorf("#%d got %v, want %v", i, claims.Subject(), "user")
+		}
+	}
+}
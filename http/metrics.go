@@ -0,0 +1,127 @@
+package http
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// MetricsPath is the conventional path metrics are exposed on.
+const MetricsPath = "/metrics"
+
+var (
+	serverRequests = metrics.NewCounter(
+		"http_server_requests_total",
+		"Total number of HTTP requests handled by the server.",
+		"method", "route", "status",
+	)
+	serverDuration = metrics.NewHistogram(
+		"http_server_request_duration_seconds",
+		"Duration of HTTP requests handled by the server.",
+		metrics.DefBuckets,
+		"method", "route", "status",
+	)
+	clientDuration = metrics.NewHistogram(
+		"http_client_request_duration_seconds",
+		"Duration of outbound HTTP requests.",
+		metrics.DefBuckets,
+		"method", "host", "status",
+	)
+)
+
+func init() {
+	metrics.Register(serverRequests, serverDuration, clientDuration)
+}
+
+// ServeMetrics registers route at path exposing metrics of the default registry, e.g. MetricsPath.
+// Metrics are not exposed unless called, restrict access to the route as they reveal service internals.
+func (a *App) ServeMetrics(path string) *mux.Route {
+	return a.API.Handle(path, metrics.Handler()).Methods(http.MethodGet)
+}
+
+// instrument is a middleware recording request counts and durations by route and status.
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := newStatusWriter(w)
+
+		next.ServeHTTP(sw, r)
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		status := strconv.Itoa(sw.Status())
+		serverRequests.Inc(r.Method, route, status)
+		serverDuration.ObserveSince(start, r.Method, route, status)
+	})
+}
+
+// statusWriter is http.ResponseWriter recording response status code and size.
+type statusWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+// newStatusWriter constructs a new statusWriter wrapping w.
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w}
+}
+
+// Status returns response status code.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http: response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns underlying http.ResponseWriter, it is used by http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestServeMetrics verifies metrics are exposed only once mounted.
+func TestServeMetrics(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+
+	var testcases = []struct {
+		mount  bool
+		status int
+	}{
+		{mount: false, status: http.StatusNotFound},
+		{mount: true, status: http.StatusOK},
+	}
+
+	for i, tt := range testcases {
+		if tt.mount {
+			app.ServeMetrics(MetricsPath)
+		}
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
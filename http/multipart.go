@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// multipartPart is a single part of MultipartForm.
+type multipartPart struct {
+	field    string
+	value    string
+	filename string
+	path     string    // file streamed from disk
+	reader   io.Reader // arbitrary file content
+}
+
+// MultipartForm is a multipart/form-data request body streamed part by part,
+// files are read only while the request is being sent.
+type MultipartForm struct {
+	parts []multipartPart
+}
+
+// NewMultipartForm constructs a new empty MultipartForm.
+func NewMultipartForm() *MultipartForm {
+	return &MultipartForm{}
+}
+
+// AddField adds a form field.
+func (f *MultipartForm) AddField(field, value string) *MultipartForm {
+	f.parts = append(f.parts, multipartPart{field: field, value: value})
+	return f
+}
+
+// AddFile adds a file part streamed from file at path.
+func (f *MultipartForm) AddFile(field, path string) *MultipartForm {
+	f.parts = append(f.parts, multipartPart{field: field, filename: filepath.Base(path), path: path})
+	return f
+}
+
+// AddReader adds a file part named filename with content read from r.
+func (f *MultipartForm) AddReader(field, filename string, r io.Reader) *MultipartForm {
+	f.parts = append(f.parts, multipartPart{field: field, filename: filename, reader: r})
+	return f
+}
+
+// Reader returns reader streaming encoded form along with its content type.
+func (f *MultipartForm) Reader() (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(f.write(mw))
+	}()
+
+	return pr, mw.FormDataContentType()
+}
+
+// write writes form parts to mw.
+func (f *MultipartForm) write(mw *multipart.Writer) error {
+	for _, p := range f.parts {
+		if p.filename == "" {
+			if err := mw.WriteField(p.field, p.value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		w, err := mw.CreateFormFile(p.field, p.filename)
+		if err != nil {
+			return err
+		}
+
+		r := p.reader
+		if p.path != "" {
+			file, err := os.Open(p.path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// Upload sends form to uri using method streaming its parts.
+func (c *Client) Upload(ctx context.Context, method, uri string, form *MultipartForm, options ...RequestOption) (*http.Response, error) {
+	body, contentType := form.Reader()
+	defer body.Close()
+
+	options = append([]RequestOption{newRequestOption(func(r *http.Request) {
+		r.Header.Set("Content-Type", contentType)
+	})}, options...)
+
+	return c.Stream(ctx, method, uri, body, options...)
+}
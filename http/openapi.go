@@ -0,0 +1,591 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/validator"
+
+	"github.com/gorilla/mux"
+)
+
+// OpenAPIVersion is the version of generated OpenAPI documents.
+const OpenAPIVersion = "3.1.0"
+
+// defaultOperationMaxBody is the default limit of validated request body size.
+const defaultOperationMaxBody = 1 << 20
+
+// Operation describes a typed route registered by App.HandleOperation.
+//
+// Request and response schemas are derived from Request and Response types by reflection.
+// Struct fields are described by their json tags, fields tagged with path or query are
+// described as parameters instead, ie. `path:"id"`. Non pointer fields without omitempty
+// are required. Further constraints are checked by Validate method of the request type,
+// see validator.Validator.
+type Operation struct {
+	Method      string
+	Path        string // mux path template, ie. /orders/{id}
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+
+	Request  RequestUnmarshaler // value of request type, nil if operation has no request
+	Response Marshaler          // value of response type, nil if operation has no response body
+	Status   int                // response status code, defaults to 200
+
+	Validate bool  // whether request parameters and body are validated against their schemas and by validator.Validator
+	MaxBody  int64 // limit of validated request body size, defaults to 1MB
+	Handler  http.Handler
+}
+
+// OpenAPIInfo provides metadata about the API.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPI represents OpenAPI document.
+type OpenAPI struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components,omitempty"`
+}
+
+// OpenAPIComponents holds reusable objects of OpenAPI document.
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// OpenAPIOperation describes a single API operation on a path.
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter describes a single operation parameter.
+type OpenAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// OpenAPIRequestBody describes operation request body.
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse describes operation response.
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType describes content of a media type.
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema represents JSON Schema of a value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// operations holds operations registered within App.
+type operations struct {
+	mu   sync.Mutex // guard fields below
+	list []*Operation
+}
+
+// HandleOperation registers a new route described by op.
+func (a *App) HandleOperation(op Operation) *mux.Route {
+	if op.Status == 0 {
+		op.Status = http.StatusOK
+	}
+	if op.MaxBody == 0 {
+		op.MaxBody = defaultOperationMaxBody
+	}
+
+	h := op.Handler
+	if op.Validate && op.Request != nil {
+		h = validateRequest(newSchemaBuilder(), reflect.TypeOf(op.Request), op.MaxBody, h)
+	}
+
+	a.operations.mu.Lock()
+	a.operations.list = append(a.operations.list, &op)
+	a.operations.mu.Unlock()
+
+	return a.API.Handle(op.Path, h).Methods(op.Method)
+}
+
+// OpenAPI generates OpenAPI document describing registered operations.
+func (a *App) OpenAPI(info OpenAPIInfo) *OpenAPI {
+	a.operations.mu.Lock()
+	ops := append([]*Operation(nil), a.operations.list...)
+	a.operations.mu.Unlock()
+
+	sb := newSchemaBuilder()
+	doc := &OpenAPI{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+
+	for _, op := range ops {
+		path := openAPIPath(op.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = sb.operation(op)
+	}
+
+	if len(sb.schemas) > 0 {
+		doc.Components.Schemas = sb.schemas
+	}
+
+	return doc
+}
+
+// ServeOpenAPI registers route at path serving OpenAPI document of registered operations.
+func (a *App) ServeOpenAPI(path string, info OpenAPIInfo) *mux.Route {
+	return a.API.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, a.OpenAPI(info))
+	}).Methods(http.MethodGet)
+}
+
+// pathVariable matches mux path variables along with their patterns.
+var pathVariable = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// openAPIPath converts mux path template into OpenAPI path.
+func openAPIPath(path string) string {
+	return pathVariable.ReplaceAllString(path, "{$1}")
+}
+
+// schemaBuilder builds schemas of Go types collecting named struct schemas as components.
+type schemaBuilder struct {
+	schemas map[string]*Schema
+}
+
+// newSchemaBuilder constructs a new schemaBuilder.
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{schemas: make(map[string]*Schema)}
+}
+
+// operation builds OpenAPIOperation describing op.
+func (sb *schemaBuilder) operation(op *Operation) *OpenAPIOperation {
+	o := &OpenAPIOperation{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*OpenAPIResponse),
+	}
+
+	for _, m := range pathVariable.FindAllStringSubmatch(op.Path, -1) {
+		o.Parameters = append(o.Parameters, &OpenAPIParameter{
+			Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+		})
+	}
+
+	if op.Request != nil {
+		t := indirect(reflect.TypeOf(op.Request))
+		body := sb.parameters(o, t)
+		if body {
+			o.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content: map[string]*OpenAPIMediaType{
+					ContentTypeJSON: {Schema: sb.schema(t)},
+				},
+			}
+		}
+	}
+
+	res := &OpenAPIResponse{Description: http.StatusText(op.Status)}
+	if op.Response != nil {
+		res.Content = map[string]*OpenAPIMediaType{
+			ContentTypeJSON: {Schema: sb.schema(reflect.TypeOf(op.Response))},
+		}
+	}
+	o.Responses[strconv.Itoa(op.Status)] = res
+
+	return o
+}
+
+// parameters describes path and query tagged fields of struct t as parameters of o.
+// It reports whether t has any fields forming request body.
+func (sb *schemaBuilder) parameters(o *OpenAPIOperation, t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+
+	body := false
+	for _, f := range visibleFields(t) {
+		in, name := "", ""
+		if name = f.Tag.Get("path"); name != "" {
+			in = "path"
+		} else if name = f.Tag.Get("query"); name != "" {
+			in = "query"
+		}
+
+		if in == "" {
+			if _, ok := jsonName(f); ok {
+				body = true
+			}
+			continue
+		}
+
+		param := &OpenAPIParameter{Name: name, In: in, Schema: sb.schema(f.Type)}
+		param.Required = in == "path"
+
+		// path parameters are already derived from path template, refine their schema
+		replaced := false
+		for i, p := range o.Parameters {
+			if p.In == in && p.Name == name {
+				o.Parameters[i], replaced = param, true
+			}
+		}
+		if !replaced {
+			o.Parameters = append(o.Parameters, param)
+		}
+	}
+
+	return body
+}
+
+// schema returns schema of type t, named structs are referenced as components.
+func (sb *schemaBuilder) schema(t reflect.Type) *Schema {
+	t = indirect(t)
+
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(json.RawMessage{}):
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: sb.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: sb.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return sb.object(t)
+		}
+		name := schemaName(t)
+		if _, ok := sb.schemas[name]; !ok {
+			sb.schemas[name] = &Schema{} // placeholder breaks recursion
+			sb.schemas[name] = sb.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	return &Schema{}
+}
+
+// object returns object schema of struct t describing its JSON body fields.
+func (sb *schemaBuilder) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, f := range visibleFields(t) {
+		if f.Tag.Get("path") != "" || f.Tag.Get("query") != "" {
+			continue
+		}
+		name, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+		s.Properties[name] = sb.schema(f.Type)
+		if required(f) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// indirect returns type t points to, if t is a pointer.
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// visibleFields returns exported fields of struct t including promoted ones.
+func visibleFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for _, f := range reflect.VisibleFields(t) {
+		if f.IsExported() && !f.Anonymous {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// jsonName returns JSON name of struct field f, false if field is omitted from JSON.
+func jsonName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return f.Name, true
+}
+
+// required reports whether struct field f must be present in JSON, ie. it is neither
+// a pointer nor tagged with omitempty.
+func required(f reflect.StructField) bool {
+	if f.Type.Kind() == reflect.Pointer {
+		return false
+	}
+	_, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			return false
+		}
+	}
+	return true
+}
+
+// invalidSchemaName matches characters not allowed in component names.
+var invalidSchemaName = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// schemaName returns component name of named type t qualified by its package path,
+// ie. github.com.acme.orders.Order, so that equally named types of different packages do not collide.
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	if pkg := t.PkgPath(); pkg != "" {
+		name = strings.ReplaceAll(pkg, "/", ".") + "." + name
+	}
+	return invalidSchemaName.ReplaceAllString(name, "_")
+}
+
+// ErrSchemaViolation is rendered when request body does not conform to its schema.
+var ErrSchemaViolation = errors.New("request does not conform to schema")
+
+// validateRequest returns handler validating path and query parameters and JSON request body of at most
+// limit bytes against schema of type t, empty body is not validated. Request is then unmarshaled into
+// a new value of type t validated by its Validate method, if any.
+func validateRequest(sb *schemaBuilder, t reflect.Type, limit int64, next http.Handler) http.Handler {
+	t = indirect(t)
+	params := &OpenAPIOperation{}
+	hasBody := sb.parameters(params, t) // request body is described by non parameter fields only
+	schema := sb.schema(t)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := sb.validateParameters(params.Parameters, r); err != nil {
+			RenderError(w, r, http.StatusBadRequest, errors.Wrap(err, ErrSchemaViolation.Error()))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			RenderError(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		if err != nil {
+			RenderError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		r.Body.Close()
+
+		if hasBody && len(bytes.TrimSpace(body)) > 0 {
+			var v any
+			if err := json.Unmarshal(body, &v); err != nil {
+				RenderError(w, r, http.StatusBadRequest, errors.Wrap(err, ErrSchemaViolation.Error()))
+				return
+			}
+
+			if err := sb.validate(schema, v, "body"); err != nil {
+				RenderError(w, r, http.StatusBadRequest, errors.Wrap(err, ErrSchemaViolation.Error()))
+				return
+			}
+		}
+
+		if req, ok := reflect.New(t).Interface().(RequestUnmarshaler); ok {
+			if vr, ok := req.(validator.Validator); ok {
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				if err := UnmarshalRequest(r, req); err != nil {
+					RenderError(w, r, http.StatusBadRequest, err)
+					return
+				}
+				if err := validator.Validate(vr); err != nil {
+					RenderError(w, r, http.StatusBadRequest, err)
+					return
+				}
+			}
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validateParameters validates path and query parameters of r against their schemas.
+func (sb *schemaBuilder) validateParameters(params []*OpenAPIParameter, r *http.Request) error {
+	query := r.URL.Query()
+	for _, p := range params {
+		var values []string
+		switch p.In {
+		case "path":
+			if v, ok := mux.Vars(r)[p.Name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = query[p.Name]
+		}
+		if err := sb.validateParameter(p.Schema, values, p.In+"."+p.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateParameter validates raw values of a parameter against schema s, values of array
+// parameters are validated against schema of its items.
+func (sb *schemaBuilder) validateParameter(s *Schema, values []string, path string) error {
+	if s.Type == "array" {
+		for i, v := range values {
+			if err := sb.validateParameter(s.Items, []string{v}, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, v := range values {
+		var value any = v
+		switch s.Type {
+		case "integer", "number":
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return errors.Newf("%s: expected %s", path, s.Type)
+			}
+			value = n
+		case "boolean":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return errors.Newf("%s: expected boolean", path)
+			}
+			value = b
+		case "string":
+		default:
+			continue // structured parameters are not described
+		}
+		if err := sb.validate(s, value, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate validates decoded JSON value v against schema s.
+func (sb *schemaBuilder) validate(s *Schema, v any, path string) error {
+	if s.Ref != "" {
+		s = sb.schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	if v == nil {
+		return nil // nullability is not described
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return errors.Newf("%s: expected object", path)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return errors.Newf("%s.%s: required", path, name)
+			}
+		}
+		for name, value := range obj {
+			if p, ok := s.Properties[name]; ok {
+				if err := sb.validate(p, value, path+"."+name); err != nil {
+					return err
+				}
+			} else if s.AdditionalProperties != nil {
+				if err := sb.validate(s.AdditionalProperties, value, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return errors.Newf("%s: expected array", path)
+		}
+		for i, e := range arr {
+			if err := sb.validate(s.Items, e, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return errors.Newf("%s: expected string", path)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return errors.Newf("%s: expected date-time", path)
+			}
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok {
+			return errors.Newf("%s: expected %s", path, s.Type)
+		}
+		if s.Type == "integer" && n != float64(int64(n)) {
+			return errors.Newf("%s: expected integer", path)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return errors.Newf("%s: expected boolean", path)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/idempotency"
+)
+
+type createOrderRequest struct {
+	ID       string   `path:"id" json:"-"`
+	DryRun   bool     `query:"dry_run" json:"-"`
+	Customer string   `json:"customer"`
+	Quantity int      `json:"quantity"`
+	Notes    []string `json:"notes,omitempty"`
+	Comment  *string  `json:"comment"`
+}
+
+func (r *createOrderRequest) UnmarshalHTTPRequest(req *http.Request) error {
+	return json.NewDecoder(req.Body).Decode(r)
+}
+
+func (r *createOrderRequest) Validate() error {
+	if r.Customer == "" {
+		return errors.New("customer is required")
+	}
+	if r.Quantity < 1 || r.Quantity > 10 {
+		return errors.New("quantity out of bounds")
+	}
+	return nil
+}
+
+// Record collides by name with idempotency.Record.
+type Record struct {
+	Status string `json:"status"`
+}
+
+type orderResponse struct {
+	ID      string             `json:"id"`
+	Record  Record             `json:"record"`
+	Request idempotency.Record `json:"request"`
+}
+
+func (r *orderResponse) MarshalHTTP(w http.ResponseWriter) error {
+	return WriteJSON(w, http.StatusCreated, r)
+}
+
+// TestOpenAPI verifies OpenAPI document generation and request validation.
+func TestOpenAPI(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+	app.HandleOperation(Operation{
+		Method:   http.MethodPut,
+		Path:     "/orders/{id:[0-9]+}",
+		ID:       "createOrder",
+		Request:  &createOrderRequest{},
+		Response: &orderResponse{},
+		Status:   http.StatusCreated,
+		Validate: true,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}),
+	})
+	app.ServeOpenAPI("/openapi.json", OpenAPIInfo{Title: "orders", Version: "1"})
+
+	rec := httptest.NewRecorder()
+	app.API.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var doc OpenAPI
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	op := doc.Paths["/orders/{id}"]["put"]
+	if op == nil {
+		t.Fatalf("got paths %v, want /orders/{id} put operation", doc.Paths)
+	}
+	if got, want := len(op.Parameters), 2; got != want {
+		t.Errorf("got %d parameters, want %d", got, want)
+	}
+	if op.Responses["201"] == nil {
+		t.Errorf("got responses %v, want 201", op.Responses)
+	}
+	schema := doc.Components.Schemas["github.com.deividaspetraitis.go.http.createOrderRequest"]
+	if schema == nil {
+		t.Fatalf("got schemas %v, want createOrderRequest", doc.Components.Schemas)
+	}
+	if got, want := strings.Join(schema.Required, ","), "customer,quantity"; got != want {
+		t.Errorf("got required %v, want %v", got, want)
+	}
+	if _, ok := schema.Properties["DryRun"]; ok {
+		t.Errorf("got query parameter described as body property")
+	}
+	for _, name := range []string{"github.com.deividaspetraitis.go.http.Record", "github.com.deividaspetraitis.go.idempotency.Record"} {
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("got schemas %v, want %v", doc.Components.Schemas, name)
+		}
+	}
+
+	var testcases = []struct {
+		body   string
+		status int
+	}{
+		{body: `{"customer":"c","quantity":2}`, status: http.StatusCreated},
+		{body: `{"customer":"c"}`, status: http.StatusBadRequest},
+		{body: `{"customer":"c","quantity":11}`, status: http.StatusBadRequest},
+		{body: `{"customer":"","quantity":1}`, status: http.StatusBadRequest},
+		{body: `{"customer":"c","quantity":1,"notes":[1]}`, status: http.StatusBadRequest},
+		{body: `{"customer":"c","quantity":"1"}`, status: http.StatusBadRequest},
+		{body: `not json`, status: http.StatusBadRequest},
+		{body: `{"customer":"` + strings.Repeat("c", 1<<20) + `","quantity":1}`, status: http.StatusRequestEntityTooLarge},
+	}
+
+	for i, tt := range testcases {
+		rec := httptest.NewRecorder()
+		app.API.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/orders/1", strings.NewReader(tt.body)))
+		if got, want := rec.Code, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+type getOrderRequest struct {
+	ID    int      `path:"id" json:"-"`
+	Limit int      `query:"limit" json:"-"`
+	Tags  []bool   `query:"tag" json:"-"`
+	Sort  []string `query:"sort" json:"-"`
+}
+
+func (r *getOrderRequest) UnmarshalHTTPRequest(req *http.Request) error {
+	return nil
+}
+
+// TestOpenAPIValidateParameters verifies operations without body validate their parameters only.
+func TestOpenAPIValidateParameters(t *testing.T) {
+	app := NewApp(make(chan os.Signal, 1))
+	app.HandleOperation(Operation{
+		Method:   http.MethodGet,
+		Path:     "/orders/{id}",
+		ID:       "getOrder",
+		Request:  &getOrderRequest{},
+		Validate: true,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	})
+
+	var testcases = []struct {
+		uri    string
+		status int
+	}{
+		{uri: "/orders/1", status: http.StatusOK},
+		{uri: "/orders/1?limit=10&tag=true&tag=false&sort=id", status: http.StatusOK},
+		{uri: "/orders/a", status: http.StatusBadRequest},
+		{uri: "/orders/1?limit=ten", status: http.StatusBadRequest},
+		{uri: "/orders/1?limit=1.5", status: http.StatusBadRequest},
+		{uri: "/orders/1?tag=true&tag=maybe", status: http.StatusBadRequest},
+	}
+
+	for i, tt := range testcases {
+		rec := httptest.NewRecorder()
+		app.API.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.uri, nil))
+		if got, want := rec.Code, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v: %s", i, got, want, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/log"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrForbidden is rendered when principal is not allowed to perform request.
+	ErrForbidden = errors.New("forbidden")
+
+	ErrMissingRole  = errors.New("missing required role")
+	ErrMissingScope = errors.New("missing required scope")
+	ErrNotOwner     = errors.New("not a resource owner")
+)
+
+// Policy is any type capable to decide whether principal is allowed to perform request.
+type Policy interface {
+	// Authorize returns nil if p is allowed to perform r, otherwise reason of denial is returned.
+	Authorize(r *http.Request, p *Principal) error
+}
+
+// PolicyFunc is an adapter to allow the use of ordinary functions as Policy.
+type PolicyFunc func(r *http.Request, p *Principal) error
+
+// Authorize implements Policy.
+func (f PolicyFunc) Authorize(r *http.Request, p *Principal) error {
+	return f(r, p)
+}
+
+// RequireRoles returns Policy requiring principal to be granted all roles.
+func RequireRoles(roles ...string) Policy {
+	return PolicyFunc(func(r *http.Request, p *Principal) error {
+		for _, role := range roles {
+			if !p.HasRole(role) {
+				return errors.Wrap(ErrMissingRole, role)
+			}
+		}
+		return nil
+	})
+}
+
+// RequireAnyRole returns Policy requiring principal to be granted at least one of roles.
+func RequireAnyRole(roles ...string) Policy {
+	return PolicyFunc(func(r *http.Request, p *Principal) error {
+		for _, role := range roles {
+			if p.HasRole(role) {
+				return nil
+			}
+		}
+		return ErrMissingRole
+	})
+}
+
+// RequireScopes returns Policy requiring principal to be granted all scopes.
+func RequireScopes(scopes ...string) Policy {
+	return PolicyFunc(func(r *http.Request, p *Principal) error {
+		for _, scope := range scopes {
+			if !p.HasScope(scope) {
+				return errors.Wrap(ErrMissingScope, scope)
+			}
+		}
+		return nil
+	})
+}
+
+// RequireOwner returns Policy requiring mux path variable name to match principal subject.
+func RequireOwner(name string) Policy {
+	return PolicyFunc(func(r *http.Request, p *Principal) error {
+		if v, ok := mux.Vars(r)[name]; !ok || v != p.Subject {
+			return ErrNotOwner
+		}
+		return nil
+	})
+}
+
+// Allow returns Policy allowing requests for which predicate fn reports true.
+func Allow(fn func(r *http.Request, p *Principal) bool) Policy {
+	return PolicyFunc(func(r *http.Request, p *Principal) error {
+		if !fn(r, p) {
+			return ErrForbidden
+		}
+		return nil
+	})
+}
+
+// AllOf returns Policy allowing requests allowed by every policy.
+// AllOf without policies denies every request.
+func AllOf(policies ...Policy) Policy {
+	return PolicyFunc(func(r *http.Request, p *Principal) error {
+		if len(policies) == 0 {
+			return ErrForbidden
+		}
+		for _, policy := range policies {
+			if err := policy.Authorize(r, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AnyOf returns Policy allowing requests allowed by at least one policy.
+// AnyOf without policies denies every request.
+func AnyOf(policies ...Policy) Policy {
+	return PolicyFunc(func(r *http.Request, p *Principal) error {
+		err := ErrForbidden
+		for _, policy := range policies {
+			if err = policy.Authorize(r, p); err == nil {
+				return nil
+			}
+		}
+		return err
+	})
+}
+
+// Authorize returns middleware allowing only requests permitted by policy.
+// Request must be authenticated beforehand, see Authenticate.
+// Denials are written to the audit log, grants are logged at debug level.
+func Authorize(policy Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				audit(r, nil, ErrUnauthenticated)
+				unauthenticated(w, r, ErrNoCredentials)
+				return
+			}
+
+			if err := policy.Authorize(r, p); err != nil {
+				audit(r, p, err)
+				RenderError(w, r, http.StatusForbidden, ErrForbidden)
+				return
+			}
+
+			audit(r, p, nil)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HandleAuthorized registers a new route with a matcher for the URL path
+// serving requests permitted by policy only.
+func (a *App) HandleAuthorized(path string, policy Policy, h http.Handler) *mux.Route {
+	return a.API.Handle(path, Authorize(policy)(h))
+}
+
+// audit writes authorization decision to the audit log.
+func audit(r *http.Request, p *Principal, err error) {
+	fields := log.Fields{
+		"audit":  "authorization",
+		"method": r.Method,
+		"path":   r.URL.Path,
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			fields["route"] = tpl
+		}
+	}
+	if p != nil {
+		fields["subject"] = p.Subject
+		fields["auth_method"] = p.Method
+	}
+
+	if err != nil {
+		fields["allowed"] = false
+		log.WithFields(fields).WithError(err).Warn("authorization denied")
+		return
+	}
+
+	fields["allowed"] = true
+	log.WithFields(fields).Debug("authorization granted")
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deividaspetraitis/go/errors"
+
+	"github.com/gorilla/mux"
+)
+
+// TestPolicies verifies policy decisions and their composition.
+func TestPolicies(t *testing.T) {
+	p := &Principal{
+		Subject: "alice",
+		Roles:   []string{"reader", "writer"},
+		Scopes:  []string{"orders:read"},
+	}
+	r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/users/alice", nil), map[string]string{"user": "alice"})
+
+	allow := Allow(func(r *http.Request, p *Principal) bool { return true })
+	deny := Allow(func(r *http.Request, p *Principal) bool { return false })
+
+	var testcases = []struct {
+		policy Policy
+		err    error
+	}{
+		{policy: RequireRoles("reader", "writer")},
+		{policy: RequireRoles("reader", "admin"), err: ErrMissingRole},
+		{policy: RequireRoles()},
+		{policy: RequireAnyRole("admin", "writer")},
+		{policy: RequireAnyRole("admin"), err: ErrMissingRole},
+		{policy: RequireAnyRole(), err: ErrMissingRole},
+		{policy: RequireScopes("orders:read")},
+		{policy: RequireScopes("orders:read", "orders:write"), err: ErrMissingScope},
+		{policy: RequireOwner("user")},
+		{policy: RequireOwner("owner"), err: ErrNotOwner},
+		{policy: allow},
+		{policy: deny, err: ErrForbidden},
+		{policy: AllOf(allow, RequireRoles("reader"))},
+		{policy: AllOf(allow, RequireScopes("orders:write")), err: ErrMissingScope},
+		{policy: AllOf(), err: ErrForbidden},
+		{policy: AnyOf(deny, RequireRoles("reader"))},
+		{policy: AnyOf(deny, RequireRoles("admin")), err: ErrMissingRole},
+		{policy: AnyOf(), err: ErrForbidden},
+	}
+
+	for i, tt := range testcases {
+		if err := tt.policy.Authorize(r, p); !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// TestAuthorize verifies authorization middleware maps outcomes to response status.
+func TestAuthorize(t *testing.T) {
+	var testcases = []struct {
+		principal *Principal
+		policy    Policy
+
+		status int
+	}{
+		{principal: nil, policy: RequireRoles(), status: http.StatusUnauthorized},
+		{principal: &Principal{Subject: "alice"}, policy: RequireRoles("admin"), status: http.StatusForbidden},
+		{principal: &Principal{Subject: "alice", Roles: []string{"admin"}}, policy: RequireRoles("admin"), status: http.StatusOK},
+	}
+
+	for i, tt := range testcases {
+		handler := Authorize(tt.policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.principal != nil {
+			r = r.WithContext(ContextWithPrincipal(r.Context(), tt.principal))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/log"
+)
+
+// ErrRateLimited is rendered when client exceeds its rate limit.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitState is a per key state kept by rate limit algorithms.
+type RateLimitState struct {
+	Tokens   float64   // token bucket: available tokens
+	Updated  time.Time // token bucket: last refill time
+	Window   time.Time // sliding window: start of the current window
+	Current  int64     // sliding window: hits within current window
+	Previous int64     // sliding window: hits within previous window
+}
+
+// RateLimitResult is an outcome of a single rate limit decision.
+type RateLimitResult struct {
+	Allowed   bool          // whether request is allowed
+	Limit     int64         // request quota
+	Remaining int64         // remaining quota
+	Reset     time.Duration // time until quota resets
+}
+
+// RateLimitAlgorithm is any type capable to make rate limit decisions.
+type RateLimitAlgorithm interface {
+	// Take consumes a single request from state at time now.
+	Take(state *RateLimitState, now time.Time) RateLimitResult
+
+	// TTL returns duration after which idle state may be discarded.
+	TTL() time.Duration
+}
+
+// RateLimitStore is any type capable to persist rate limit state.
+type RateLimitStore interface {
+	// Update atomically applies fn to the state stored under key and stores
+	// the result which expires ttl after now. Missing keys start with zero state.
+	Update(ctx context.Context, key string, now time.Time, ttl time.Duration, fn func(state *RateLimitState)) error
+}
+
+// tokenBucket implements token bucket RateLimitAlgorithm.
+type tokenBucket struct {
+	rate  float64 // tokens per second
+	burst int64   // bucket capacity
+}
+
+// TokenBucket returns RateLimitAlgorithm refilling limit tokens every per duration
+// into a bucket holding at most burst tokens. It panics unless all arguments are positive.
+func TokenBucket(limit int64, per time.Duration, burst int64) RateLimitAlgorithm {
+	if limit <= 0 || per <= 0 || burst <= 0 {
+		panic(fmt.Sprintf("http: invalid token bucket limit %d per %v burst %d", limit, per, burst))
+	}
+	return &tokenBucket{
+		rate:  float64(limit) / per.Seconds(),
+		burst: burst,
+	}
+}
+
+// Take implements RateLimitAlgorithm.
+func (b *tokenBucket) Take(state *RateLimitState, now time.Time) RateLimitResult {
+	if state.Updated.IsZero() {
+		state.Tokens = float64(b.burst)
+	} else {
+		elapsed := now.Sub(state.Updated).Seconds()
+		state.Tokens = math.Min(float64(b.burst), state.Tokens+elapsed*b.rate)
+	}
+	state.Updated = now
+
+	result := RateLimitResult{Limit: b.burst}
+	if state.Tokens >= 1 {
+		state.Tokens--
+		result.Allowed = true
+		result.Reset = b.duration(float64(b.burst) - state.Tokens)
+	} else {
+		result.Reset = b.duration(1 - state.Tokens)
+	}
+	result.Remaining = int64(state.Tokens)
+
+	return result
+}
+
+// TTL implements RateLimitAlgorithm.
+func (b *tokenBucket) TTL() time.Duration {
+	return b.duration(float64(b.burst))
+}
+
+// duration returns time needed to refill n tokens.
+func (b *tokenBucket) duration(n float64) time.Duration {
+	return time.Duration(n / b.rate * float64(time.Second))
+}
+
+// slidingWindow implements sliding window counter RateLimitAlgorithm.
+type slidingWindow struct {
+	limit  int64
+	window time.Duration
+}
+
+// SlidingWindow returns RateLimitAlgorithm allowing at most limit requests within any window.
+// Hits of the previous window are weighted by its overlap with the sliding window.
+// It panics unless all arguments are positive.
+func SlidingWindow(limit int64, window time.Duration) RateLimitAlgorithm {
+	if limit <= 0 || window <= 0 {
+		panic(fmt.Sprintf("http: invalid sliding window limit %d window %v", limit, window))
+	}
+	return &slidingWindow{
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Take implements RateLimitAlgorithm.
+func (s *slidingWindow) Take(state *RateLimitState, now time.Time) RateLimitResult {
+	start := now.Truncate(s.window)
+	if !state.Window.Equal(start) {
+		if state.Window.Equal(start.Add(-s.window)) {
+			state.Previous = state.Current
+		} else {
+			state.Previous = 0
+		}
+		state.Window, state.Current = start, 0
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(s.window)
+	estimated := float64(state.Previous)*weight + float64(state.Current)
+
+	result := RateLimitResult{
+		Limit: s.limit,
+		Reset: s.window - elapsed,
+	}
+	if estimated+1 <= float64(s.limit) {
+		state.Current++
+		estimated++
+		result.Allowed = true
+	}
+	result.Remaining = max(0, s.limit-int64(math.Ceil(estimated)))
+
+	return result
+}
+
+// TTL implements RateLimitAlgorithm.
+func (s *slidingWindow) TTL() time.Duration {
+	return 2 * s.window
+}
+
+// memoryRateLimitEntry is a single entry of MemoryRateLimitStore.
+type memoryRateLimitEntry struct {
+	state   RateLimitState
+	expires time.Time
+}
+
+// MemoryRateLimitStore is in-memory RateLimitStore, expired entries are swept lazily.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex // guard fields below
+	entries map[string]*memoryRateLimitEntry
+	swept   time.Time
+}
+
+// NewMemoryRateLimitStore constructs and returns new MemoryRateLimitStore instance.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries: make(map[string]*memoryRateLimitEntry),
+	}
+}
+
+// Update implements RateLimitStore.
+func (s *MemoryRateLimitStore) Update(ctx context.Context, key string, now time.Time, ttl time.Duration, fn func(state *RateLimitState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now, ttl)
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expires) {
+		entry = &memoryRateLimitEntry{}
+		s.entries[key] = entry
+	}
+
+	fn(&entry.state)
+	entry.expires = now.Add(ttl)
+
+	return nil
+}
+
+// sweep removes expired entries at most once per interval.
+// Caller must hold s.mu.
+func (s *MemoryRateLimitStore) sweep(now time.Time, interval time.Duration) {
+	if now.Sub(s.swept) < interval {
+		return
+	}
+	for k, v := range s.entries {
+		if now.After(v.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.swept = now
+}
+
+// KeyFunc returns rate limit key of the request.
+// Empty key means request is not rate limited.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP returns KeyFunc keying requests by client IP address.
+func KeyByIP() KeyFunc {
+	return func(r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	}
+}
+
+// KeyByHeader returns KeyFunc keying requests by value of header name.
+// Requests without the header are keyed by client IP address, omitting the header
+// must not bypass the limit.
+func KeyByHeader(name string) KeyFunc {
+	byIP := KeyByIP()
+	return func(r *http.Request) string {
+		if key := r.Header.Get(name); key != "" {
+			return name + ":" + key
+		}
+		return byIP(r)
+	}
+}
+
+// KeyBySubject returns KeyFunc keying requests by authenticated subject.
+// Anonymous requests are keyed by client IP address, they must not bypass the limit.
+func KeyBySubject() KeyFunc {
+	byIP := KeyByIP()
+	return func(r *http.Request) string {
+		if subject, _ := SubjectFromContext(r.Context()); subject != "" {
+			return "subject:" + subject
+		}
+		return byIP(r)
+	}
+}
+
+// FirstKey returns KeyFunc returning the first non empty key of fns.
+func FirstKey(fns ...KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		for _, fn := range fns {
+			if key := fn(r); key != "" {
+				return key
+			}
+		}
+		return ""
+	}
+}
+
+// RateLimitOption is modifier of a rate limit middleware.
+type RateLimitOption interface {
+	apply(*rateLimiter)
+}
+
+// newRateLimitOption constructs a new rateLimitOption.
+func newRateLimitOption(fn func(l *rateLimiter)) *rateLimitOption {
+	return &rateLimitOption{applyFn: fn}
+}
+
+// rateLimitOption is an implementation of RateLimitOption.
+type rateLimitOption struct {
+	applyFn func(l *rateLimiter)
+}
+
+// apply implements RateLimitOption.
+func (o *rateLimitOption) apply(l *rateLimiter) {
+	o.applyFn(l)
+}
+
+// WithRateLimitStore constructs RateLimitOption to persist rate limit state in store.
+func WithRateLimitStore(store RateLimitStore) RateLimitOption {
+	return newRateLimitOption(func(l *rateLimiter) {
+		l.store = store
+	})
+}
+
+// WithRateLimitPrefix constructs RateLimitOption to prefix keys, it allows to share a store among limiters.
+func WithRateLimitPrefix(prefix string) RateLimitOption {
+	return newRateLimitOption(func(l *rateLimiter) {
+		l.prefix = prefix
+	})
+}
+
+// WithRateLimitClock constructs RateLimitOption to take current time from now, defaults to time.Now.
+// The time is used by both algorithm and store.
+func WithRateLimitClock(now func() time.Time) RateLimitOption {
+	return newRateLimitOption(func(l *rateLimiter) {
+		l.now = now
+	})
+}
+
+// rateLimiter holds rate limit middleware settings.
+type rateLimiter struct {
+	algorithm RateLimitAlgorithm
+	key       KeyFunc
+	store     RateLimitStore
+	prefix    string
+	now       func() time.Time
+}
+
+// RateLimit returns middleware limiting request rate per key using given algorithm.
+// Rejected requests receive 429 response rendered by shared ErrorRenderer.
+func RateLimit(algorithm RateLimitAlgorithm, key KeyFunc, opts ...RateLimitOption) func(http.Handler) http.Handler {
+	l := &rateLimiter{
+		algorithm: algorithm,
+		key:       key,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(l)
+		}
+	}
+	if l.store == nil {
+		l.store = NewMemoryRateLimitStore()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := l.key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var result RateLimitResult
+			now := l.now()
+			if err := l.store.Update(r.Context(), l.prefix+key, now, l.algorithm.TTL(), func(state *RateLimitState) {
+				result = l.algorithm.Take(state, now)
+			}); err != nil {
+				// fail open, unavailable store must not take the service down
+				log.WithError(err).Error("rate limit: unable to update state")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reset := strconv.FormatInt(int64(math.Ceil(result.Reset.Seconds())), 10)
+			w.Header().Set("RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+			w.Header().Set("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+			w.Header().Set("RateLimit-Reset", reset)
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", reset)
+				RenderError(w, r, http.StatusTooManyRequests, ErrRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestTokenBucket verifies token bucket consumption and refill over time.
+func TestTokenBucket(t *testing.T) {
+	var (
+		algorithm = TokenBucket(1, time.Second, 2)
+		state     RateLimitState
+		start     = time.Unix(1000, 0)
+	)
+
+	var testcases = []struct {
+		elapsed time.Duration
+		want    RateLimitResult
+	}{
+		{0, RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+		{0, RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+		{0, RateLimitResult{Allowed: false, Limit: 2, Remaining: 0, Reset: time.Second}},
+		{500 * time.Millisecond, RateLimitResult{Allowed: false, Limit: 2, Remaining: 0, Reset: 500 * time.Millisecond}},
+		{time.Second, RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+		{10 * time.Second, RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+	}
+
+	for i, tt := range testcases {
+		if got := algorithm.Take(&state, start.Add(tt.elapsed)); got != tt.want {
+			t.Errorf("#%d got %+v, want %+v", i, got, tt.want)
+		}
+	}
+}
+
+// TestSlidingWindow verifies sliding window counting across window boundaries.
+func TestSlidingWindow(t *testing.T) {
+	var (
+		algorithm = SlidingWindow(2, 10*time.Second)
+		state     RateLimitState
+		start     = time.Unix(1000, 0)
+	)
+
+	var testcases = []struct {
+		elapsed time.Duration
+		want    RateLimitResult
+	}{
+		{0, RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: 10 * time.Second}},
+		{time.Second, RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 9 * time.Second}},
+		{2 * time.Second, RateLimitResult{Allowed: false, Limit: 2, Remaining: 0, Reset: 8 * time.Second}},
+		{15 * time.Second, RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 5 * time.Second}}, // half of previous window counts
+		{18 * time.Second, RateLimitResult{Allowed: false, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+		{40 * time.Second, RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: 10 * time.Second}}, // previous window is not adjacent
+	}
+
+	for i, tt := range testcases {
+		if got := algorithm.Take(&state, start.Add(tt.elapsed)); got != tt.want {
+			t.Errorf("#%d got %+v, want %+v", i, got, tt.want)
+		}
+	}
+}
+
+// TestRateLimitInvalid verifies algorithms reject non positive settings.
+func TestRateLimitInvalid(t *testing.T) {
+	var testcases = []func(){
+		func() { TokenBucket(0, time.Second, 1) },
+		func() { TokenBucket(1, 0, 1) },
+		func() { TokenBucket(1, time.Second, 0) },
+		func() { SlidingWindow(0, time.Second) },
+		func() { SlidingWindow(1, -time.Second) },
+	}
+
+	for i, fn := range testcases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("#%d got %v, want %v", i, false, true)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+// TestRateLimit verifies middleware rejects requests exceeding the limit per key.
+func TestRateLimit(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock := func() time.Time { return now }
+
+	handler := RateLimit(TokenBucket(1, time.Minute, 1), KeyByHeader("X-Key"),
+		WithRateLimitStore(NewMemoryRateLimitStore()),
+		WithRateLimitClock(clock),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var testcases = []struct {
+		key     string
+		elapsed time.Duration
+
+		status     int
+		remaining  string
+		retryAfter string
+	}{
+		{key: "a", status: http.StatusOK, remaining: "0"},
+		{key: "a", status: http.StatusTooManyRequests, remaining: "0", retryAfter: "60"},
+		{key: "b", status: http.StatusOK, remaining: "0"},
+		{key: "", status: http.StatusOK, remaining: "0"}, // keyed by client IP
+		{key: "", status: http.StatusTooManyRequests, remaining: "0", retryAfter: "60"},
+		{key: "a", elapsed: time.Minute, status: http.StatusOK, remaining: "0"},
+	}
+
+	for i, tt := range testcases {
+		now = now.Add(tt.elapsed)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.key != "" {
+			r.Header.Set("X-Key", tt.key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := w.Header().Get("RateLimit-Limit"), "1"; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := w.Header().Get("RateLimit-Remaining"), tt.remaining; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := w.Header().Get("Retry-After"), tt.retryAfter; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestKeyBySubject verifies anonymous requests are keyed by client IP address.
+func TestKeyBySubject(t *testing.T) {
+	var testcases = []struct {
+		principal *Principal
+		key       string
+	}{
+		{principal: &Principal{Subject: "alice"}, key: "subject:alice"},
+		{principal: nil, key: "192.0.2.1"},
+	}
+
+	for i, tt := range testcases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.principal != nil {
+			r = r.WithContext(ContextWithPrincipal(r.Context(), tt.principal))
+		}
+		if got, want := KeyBySubject()(r), tt.key; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+// package record implements HTTP transport recording request/response pairs to golden files
+// and replaying them, it allows testing HTTP clients without reaching remote servers.
+package record
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// Redacted replaces redacted values in golden files.
+const Redacted = "REDACTED"
+
+// ErrNoMatch is returned in replay mode when no recorded interaction matches a request.
+var ErrNoMatch = errors.New("record: no recorded interaction matches request")
+
+// Mode is a mode Recorder operates in.
+type Mode int
+
+const (
+	ModeReplay Mode = iota // serve recorded interactions, never reach remote servers
+	ModeRecord             // send requests to remote servers and record interactions
+	ModeAuto               // replay if golden file exists, record otherwise
+)
+
+// Request is a recorded HTTP request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Interaction is a recorded request along with its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Body is a recorded body, non UTF-8 bodies are encoded as base64 in golden files.
+type Body []byte
+
+// body is a representation of Body in golden files.
+type body struct {
+	Data     string `json:"data"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(body{Data: string(b)})
+	}
+	return json.Marshal(body{Data: base64.StdEncoding.EncodeToString(b), Encoding: "base64"})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var v body
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Encoding == "base64" {
+		d, err := base64.StdEncoding.DecodeString(v.Data)
+		if err != nil {
+			return err
+		}
+		*b = d
+		return nil
+	}
+	*b = Body(v.Data)
+	return nil
+}
+
+// Matcher reports whether request got matches recorded request.
+type Matcher func(got, recorded *Request) bool
+
+// MatchMethod matches requests by method.
+func MatchMethod(got, recorded *Request) bool {
+	return got.Method == recorded.Method
+}
+
+// MatchPath matches requests by URL host and path.
+func MatchPath(got, recorded *Request) bool {
+	g, r := parseURL(got.URL), parseURL(recorded.URL)
+	return g.Host == r.Host && g.Path == r.Path
+}
+
+// MatchQuery matches requests by query parameters regardless of their order.
+func MatchQuery(got, recorded *Request) bool {
+	return parseURL(got.URL).Query().Encode() == parseURL(recorded.URL).Query().Encode()
+}
+
+// MatchBody matches requests by body, JSON bodies are compared semantically.
+func MatchBody(got, recorded *Request) bool {
+	if bytes.Equal(got.Body, recorded.Body) {
+		return true
+	}
+	var g, r any
+	if json.Unmarshal(got.Body, &g) != nil || json.Unmarshal(recorded.Body, &r) != nil {
+		return false
+	}
+	gb, _ := json.Marshal(g)
+	rb, _ := json.Marshal(r)
+	return bytes.Equal(gb, rb)
+}
+
+// MatchHeader returns Matcher matching requests by header name.
+func MatchHeader(name string) Matcher {
+	return func(got, recorded *Request) bool {
+		return strings.Join(got.Header.Values(name), ",") == strings.Join(recorded.Header.Values(name), ",")
+	}
+}
+
+// parseURL parses s ignoring errors, recorded URLs are valid.
+func parseURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		return &url.URL{}
+	}
+	return u
+}
+
+// Option is modifier of a Recorder.
+type Option interface {
+	apply(*Recorder)
+}
+
+// newOption constructs a new option.
+func newOption(fn func(r *Recorder)) *option {
+	return &option{applyFn: fn}
+}
+
+// option is an implementation of Option.
+type option struct {
+	applyFn func(r *Recorder)
+}
+
+// apply implements Option.
+func (o *option) apply(r *Recorder) {
+	o.applyFn(r)
+}
+
+// WithMatchers constructs Option replacing default matchers, request matches interaction if all matchers do.
+// Default matchers are MatchMethod, MatchPath, MatchQuery and MatchBody.
+func WithMatchers(m ...Matcher) Option {
+	return newOption(func(r *Recorder) {
+		r.matchers = m
+	})
+}
+
+// WithRedactHeaders constructs Option to redact values of headers in golden files.
+// Authorization, Cookie, Set-Cookie and X-API-Key headers are always redacted.
+func WithRedactHeaders(names ...string) Option {
+	return newOption(func(r *Recorder) {
+		r.headers = append(r.headers, names...)
+	})
+}
+
+// WithRedactBody constructs Option to redact request and response bodies in golden files using fn.
+func WithRedactBody(fn func(body []byte) []byte) Option {
+	return newOption(func(r *Recorder) {
+		r.redactBody = fn
+	})
+}
+
+// WithTransport constructs Option to send requests in record mode using rt.
+func WithTransport(rt http.RoundTripper) Option {
+	return newOption(func(r *Recorder) {
+		r.transport = rt
+	})
+}
+
+// RedactJSONFields returns body redactor replacing values of top level JSON object fields.
+// Non JSON bodies are left intact.
+func RedactJSONFields(fields ...string) func(body []byte) []byte {
+	return func(body []byte) []byte {
+		var v map[string]json.RawMessage
+		if err := json.Unmarshal(body, &v); err != nil {
+			return body
+		}
+		for _, f := range fields {
+			if _, ok := v[f]; ok {
+				v[f] = json.RawMessage(`"` + Redacted + `"`)
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return b
+	}
+}
+
+// Recorder is http.RoundTripper recording or replaying interactions stored in a golden file.
+type Recorder struct {
+	path       string
+	mode       Mode
+	transport  http.RoundTripper
+	matchers   []Matcher
+	headers    []string
+	redactBody func(body []byte) []byte
+
+	mu           sync.Mutex // guard fields below
+	interactions []*Interaction
+	used         []bool
+}
+
+// New constructs a new Recorder operating in mode on golden file at path.
+// In replay mode golden file is loaded immediately, in record mode it is written by Save.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		matchers:  []Matcher{MatchMethod, MatchPath, MatchQuery, MatchBody},
+		headers:   []string{"Authorization", "Cookie", "Set-Cookie", "X-API-Key"},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(r)
+		}
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+
+	if r.mode == ModeReplay {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading golden file %s", path)
+		}
+		if err := json.Unmarshal(b, &r.interactions); err != nil {
+			return nil, errors.Wrapf(err, "decoding golden file %s", path)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+
+	return r, nil
+}
+
+// Mode returns mode recorder operates in.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := Request{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: r.redactHeader(req.Header),
+		Body:   r.redact(reqBody),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, &recorded)
+	}
+
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := readBody(&res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, &Interaction{
+		Request: recorded,
+		Response: Response{
+			Status: res.StatusCode,
+			Header: r.redactHeader(res.Header),
+			Body:   r.redact(resBody),
+		},
+	})
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+// replay returns response of the first unused interaction matching recorded request.
+func (r *Recorder) replay(req *http.Request, recorded *Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || !r.match(recorded, &interaction.Request) {
+			continue
+		}
+		r.used[i] = true
+
+		return &http.Response{
+			Status:        http.StatusText(interaction.Response.Status),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, errors.Wrapf(ErrNoMatch, "%s %s in %s", recorded.Method, recorded.URL, r.path)
+}
+
+// match reports whether got matches recorded request according to all matchers.
+func (r *Recorder) match(got, recorded *Request) bool {
+	for _, m := range r.matchers {
+		if !m(got, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// Unused returns interactions which were not replayed.
+func (r *Recorder) Unused() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []*Interaction
+	for i, used := range r.used {
+		if !used {
+			unused = append(unused, r.interactions[i])
+		}
+	}
+	return unused
+}
+
+// Save writes recorded interactions to golden file, it does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(b, '\n'), 0o644)
+}
+
+// redactHeader returns copy of h with redacted header values.
+func (r *Recorder) redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range r.headers {
+		if values := h.Values(name); len(values) > 0 {
+			h.Set(name, Redacted)
+		}
+	}
+	return h
+}
+
+// redact returns redacted body.
+func (r *Recorder) redact(b []byte) []byte {
+	if r.redactBody == nil || len(b) == 0 {
+		return b
+	}
+	return r.redactBody(b)
+}
+
+// readBody reads body and replaces it with a reader over read bytes.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(*body)
+	if err != nil {
+		return nil, err
+	}
+	(*body).Close()
+	*body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
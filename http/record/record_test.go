@@ -0,0 +1,101 @@
+package record
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deividaspetraitis/go/errors"
+	gohttp "github.com/deividaspetraitis/go/http"
+)
+
+// TestRecorder verifies interactions recorded to golden file are replayed.
+func TestRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if len(b) == 0 {
+			b = []byte(r.URL.Query().Get("q"))
+		}
+		w.Write(b)
+	}))
+
+	path := filepath.Join(t.TempDir(), "testdata", "golden.json")
+
+	var testcases = []struct {
+		query string
+		body  string
+
+		want string
+		err  error
+	}{
+		{query: "a", body: `{"secret":"x"}`, want: `{"secret":"x"}`},
+		{query: "b", want: "b"},
+		{query: "c", err: ErrNoMatch},
+	}
+
+	request := func(rec *Recorder, query, body string) (string, error) {
+		client, err := gohttp.NewClient(srv.URL, gohttp.WithBearerToken("token"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.SetTransport(rec)
+
+		res, err := client.Request(context.Background(), http.MethodPost, "echo", []byte(body), gohttp.WithQueryParam("q", query))
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		return string(b), err
+	}
+
+	rec, err := New(path, ModeAuto, WithRedactBody(RedactJSONFields("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Mode(), ModeRecord; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, tt := range testcases[:2] {
+		if got, err := request(rec, tt.query, tt.body); err != nil || got != tt.want {
+			t.Errorf("#%d got %v (%v), want %v", i, got, err, tt.want)
+		}
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(golden), "token") || strings.Contains(string(golden), `\"x\"`) {
+		t.Errorf("got unredacted golden file %s", golden)
+	}
+
+	rec, err = New(path, ModeAuto, WithRedactBody(RedactJSONFields("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Mode(), ModeReplay; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, tt := range testcases {
+		got, err := request(rec, tt.query, tt.body)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if tt.err == nil && got != strings.Replace(tt.want, `"x"`, `"REDACTED"`, 1) {
+			t.Errorf("#%d got %v, want %v", i, got, tt.want)
+		}
+	}
+	if got, want := len(rec.Unused()), 0; got != want {
+		t.Errorf("got %v unused interactions, want %v", got, want)
+	}
+}
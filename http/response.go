@@ -0,0 +1,228 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// Supported content types.
+const (
+	ContentTypeJSON   = "application/json"
+	ContentTypeXML    = "application/xml"
+	ContentTypeCSV    = "text/csv"
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// ErrNotAcceptable is rendered when none of offered content types is acceptable by client.
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// WriteJSON encodes v as JSON response body with given status code.
+func WriteJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// WriteXML encodes v as XML response body with given status code.
+func WriteXML(w http.ResponseWriter, status int, v any) error {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentTypeXML)
+	w.WriteHeader(status)
+	_, err = w.Write(append([]byte(xml.Header), b...))
+	return err
+}
+
+// CSVMarshaler is any type capable to marshal itself into CSV records.
+type CSVMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+// WriteCSV encodes v as CSV response body with given status code.
+// v must be either CSVMarshaler or [][]string.
+func WriteCSV(w http.ResponseWriter, status int, v any) error {
+	records, err := csvRecords(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentTypeCSV)
+	w.WriteHeader(status)
+
+	cw := csv.NewWriter(w)
+	cw.WriteAll(records)
+	return cw.Error()
+}
+
+// csvRecords returns CSV records of v.
+func csvRecords(v any) ([][]string, error) {
+	switch v := v.(type) {
+	case CSVMarshaler:
+		return v.MarshalCSV()
+	case [][]string:
+		return v, nil
+	}
+	return nil, errors.Newf("http: %T can not be encoded as CSV", v)
+}
+
+// JSON returns Marshaler writing v as JSON response with given status code.
+func JSON(status int, v any) Marshaler {
+	return marshalerFunc(func(w http.ResponseWriter) error {
+		return WriteJSON(w, status, v)
+	})
+}
+
+// marshalerFunc is an adapter to allow the use of ordinary functions as Marshaler.
+type marshalerFunc func(w http.ResponseWriter) error
+
+// MarshalHTTP implements Marshaler.
+func (f marshalerFunc) MarshalHTTP(w http.ResponseWriter) error {
+	return f(w)
+}
+
+// Respond writes v with given status code encoded in the format negotiated by Accept header.
+// JSON, XML and CSV formats are supported, CSV only for values WriteCSV accepts.
+// If no format is acceptable 406 response is rendered.
+func Respond(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	offers := []string{ContentTypeJSON, ContentTypeXML}
+	if _, err := csvRecords(v); err == nil {
+		offers = append(offers, ContentTypeCSV)
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	switch Negotiate(r, offers...) {
+	case ContentTypeJSON:
+		return WriteJSON(w, status, v)
+	case ContentTypeXML:
+		return WriteXML(w, status, v)
+	case ContentTypeCSV:
+		return WriteCSV(w, status, v)
+	}
+
+	RenderError(w, r, http.StatusNotAcceptable, ErrNotAcceptable)
+	return ErrNotAcceptable
+}
+
+// mediaRange is a single parsed Accept header entry.
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// Negotiate returns the offered content type best matching request Accept header.
+// Offers are given in the order of server preference. If Accept header is missing or empty
+// first offer is returned, if no offer is acceptable empty string is returned.
+// Quality of an offer is given by the most specific matching media range, thus
+// offers excluded by q=0 are never returned.
+func Negotiate(r *http.Request, offers ...string) string {
+	accept := r.Header.Values("Accept")
+	if strings.TrimSpace(strings.Join(accept, "")) == "" { // missing or empty header accepts anything
+		if len(offers) > 0 {
+			return offers[0]
+		}
+		return ""
+	}
+
+	ranges := parseAccept(strings.Join(accept, ","))
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, offer := range offers {
+		q, specificity := matchAccept(ranges, offer)
+		if q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+
+	return best
+}
+
+// matchAccept returns quality and specificity of the most specific media range matching offer.
+// Specificity is -1 if no range matches.
+func matchAccept(ranges []mediaRange, offer string) (float64, int) {
+	typ, subtype, _ := strings.Cut(strings.ToLower(offer), "/")
+
+	q, specificity := 0.0, -1
+	for _, mr := range ranges {
+		s := 0
+		switch {
+		case mr.typ == typ && mr.subtype == subtype:
+			s = 2
+		case mr.typ == typ && mr.subtype == "*":
+			s = 1
+		case mr.typ == "*" && mr.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = mr.q, s
+		}
+	}
+	return q, specificity
+}
+
+// parseAccept parses Accept header value into media ranges.
+func parseAccept(s string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(fields[0])), "/")
+		if !ok {
+			continue
+		}
+
+		mr := mediaRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range fields[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(k, "q") {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					mr.q = q
+				}
+			}
+		}
+		ranges = append(ranges, mr)
+	}
+
+	return ranges
+}
+
+// NDJSONWriter streams values as newline delimited JSON.
+type NDJSONWriter struct {
+	w   http.ResponseWriter
+	enc *json.Encoder
+	rc  *http.ResponseController
+}
+
+// NewNDJSONWriter writes response header with given status code and returns NDJSONWriter.
+func NewNDJSONWriter(w http.ResponseWriter, status int) *NDJSONWriter {
+	w.Header().Set("Content-Type", ContentTypeNDJSON)
+	w.WriteHeader(status)
+	return &NDJSONWriter{
+		w:   w,
+		enc: json.NewEncoder(w),
+		rc:  http.NewResponseController(w),
+	}
+}
+
+// Encode writes v as a single line and flushes it to the client.
+func (n *NDJSONWriter) Encode(v any) error {
+	if err := n.enc.Encode(v); err != nil {
+		return err
+	}
+	if err := n.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
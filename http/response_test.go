@@ -0,0 +1,77 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestNegotiate verifies content type negotiation by Accept header.
+func TestNegotiate(t *testing.T) {
+	offers := []string{ContentTypeJSON, ContentTypeXML, ContentTypeCSV}
+
+	var testcases = []struct {
+		accept []string
+		want   string
+	}{
+		{accept: nil, want: ContentTypeJSON},
+		{accept: []string{""}, want: ContentTypeJSON},
+		{accept: []string{"garbage"}, want: ""},
+		{accept: []string{"application/xml"}, want: ContentTypeXML},
+		{accept: []string{"text/*"}, want: ContentTypeCSV},
+		{accept: []string{"*/*"}, want: ContentTypeJSON},
+		{accept: []string{"application/json;q=0.5, application/xml"}, want: ContentTypeXML},
+		{accept: []string{"application/json;q=0.5", "application/xml;q=0.8"}, want: ContentTypeXML},
+		{accept: []string{"application/*;q=0.5, application/xml"}, want: ContentTypeXML},
+		{accept: []string{"application/json;q=0, */*"}, want: ContentTypeXML},                      // excluded by the most specific range
+		{accept: []string{"application/json;q=0, application/*;q=0.1, */*"}, want: ContentTypeCSV}, // */* does not override specific q
+		{accept: []string{"application/*;q=0, text/csv;q=0"}, want: ""},
+		{accept: []string{"image/png"}, want: ""},
+		{accept: []string{"APPLICATION/XML"}, want: ContentTypeXML},
+	}
+
+	for i, tt := range testcases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, v := range tt.accept {
+			r.Header.Add("Accept", v)
+		}
+
+		if got := Negotiate(r, offers...); got != tt.want {
+			t.Errorf("#%d got %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+// TestRespond verifies values are encoded in negotiated format.
+func TestRespond(t *testing.T) {
+	type item struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	var testcases = []struct {
+		accept string
+
+		status      int
+		contentType string
+	}{
+		{accept: "", status: http.StatusOK, contentType: ContentTypeJSON},
+		{accept: "application/xml", status: http.StatusOK, contentType: ContentTypeXML},
+		{accept: "application/json;q=0, application/xml;q=0", status: http.StatusNotAcceptable},
+	}
+
+	for i, tt := range testcases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		Respond(w, r, http.StatusOK, item{Name: "a"})
+
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got := w.Header().Get("Content-Type"); tt.contentType != "" && got != tt.contentType {
+			t.Errorf("#%d got %v, want %v", i, got, tt.contentType)
+		}
+	}
+}
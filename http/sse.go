@@ -0,0 +1,284 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/es"
+	"github.com/deividaspetraitis/go/log"
+)
+
+// ContentTypeEventStream is the content type of Server-Sent Events stream.
+const ContentTypeEventStream = "text/event-stream"
+
+const (
+	defaultSSEHeartbeat = 15 * time.Second // default interval of keep alive comments
+	defaultSSEBuffer    = 64               // default number of events buffered per client
+)
+
+var (
+	// ErrInvalidLastEventID should be returned by EventSource when Last-Event-ID can not be resumed from.
+	ErrInvalidLastEventID = errors.New("invalid Last-Event-ID")
+
+	// ErrSlowConsumer is logged when a client is disconnected for not keeping up with events.
+	ErrSlowConsumer = errors.New("slow consumer")
+)
+
+// SSEEvent represents a single Server-Sent Event.
+type SSEEvent struct {
+	ID    string // event identifier sent back by clients as Last-Event-ID when reconnecting
+	Event string // event type, empty for default "message" type
+	Data  []byte // event payload
+}
+
+// EventSource is any type capable to stream events to SSE clients.
+type EventSource interface {
+	// Subscribe returns channel of events following lastEventID, or only new events if lastEventID is empty.
+	// Source must close the channel once ctx is done or it fails to deliver further events.
+	Subscribe(ctx context.Context, r *http.Request, lastEventID string) (<-chan SSEEvent, error)
+}
+
+// EventSourceFunc is an adapter to allow the use of ordinary functions as EventSource.
+type EventSourceFunc func(ctx context.Context, r *http.Request, lastEventID string) (<-chan SSEEvent, error)
+
+// Subscribe implements EventSource.
+func (f EventSourceFunc) Subscribe(ctx context.Context, r *http.Request, lastEventID string) (<-chan SSEEvent, error) {
+	return f(ctx, r, lastEventID)
+}
+
+// NotifierEventSource returns EventSource streaming JSON encoded notifications of the notifier
+// fn selects for a request, ie. an event store. Notification positions are used as SSE event IDs,
+// thus clients resume from the last position they received.
+func NotifierEventSource(fn func(r *http.Request) es.Notifier) EventSource {
+	return EventSourceFunc(func(ctx context.Context, r *http.Request, lastEventID string) (<-chan SSEEvent, error) {
+		notifications, err := fn(r).Notify(ctx, lastEventID)
+		if errors.Is(err, es.ErrInvalidPosition) {
+			return nil, errors.Wrapf(ErrInvalidLastEventID, "%q", lastEventID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ch := make(chan SSEEvent)
+		go func() {
+			defer close(ch)
+
+			for n := range notifications {
+				b, err := json.Marshal(n)
+				if err != nil {
+					log.WithError(err).WithFields(log.Fields{"type": n.Type}).Error("sse: unable to encode notification")
+					continue
+				}
+
+				select {
+				case ch <- SSEEvent{ID: n.Position, Event: n.Type, Data: b}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		return ch, nil
+	})
+}
+
+// SSEOption is modifier of a SSE handler.
+type SSEOption interface {
+	apply(*sse)
+}
+
+// newSSEOption constructs a new sseOption.
+func newSSEOption(fn func(s *sse)) *sseOption {
+	return &sseOption{applyFn: fn}
+}
+
+// sseOption is an implementation of SSEOption.
+type sseOption struct {
+	applyFn func(s *sse)
+}
+
+// apply implements SSEOption.
+func (o *sseOption) apply(s *sse) {
+	o.applyFn(s)
+}
+
+// WithSSEHeartbeat constructs SSEOption to send keep alive comments every d, zero d disables heartbeat.
+func WithSSEHeartbeat(d time.Duration) SSEOption {
+	return newSSEOption(func(s *sse) {
+		s.heartbeat = d
+	})
+}
+
+// WithSSEBuffer constructs SSEOption to buffer up to n events per client, n below 1 buffers a single event.
+// Clients falling behind by more than n events are disconnected and expected to resume using Last-Event-ID.
+func WithSSEBuffer(n int) SSEOption {
+	return newSSEOption(func(s *sse) {
+		s.buffer = max(n, 1)
+	})
+}
+
+// WithSSEWriteTimeout constructs SSEOption limiting time a single write to client is allowed to take.
+func WithSSEWriteTimeout(d time.Duration) SSEOption {
+	return newSSEOption(func(s *sse) {
+		s.writeTimeout = d
+	})
+}
+
+// WithSSERetry constructs SSEOption advising clients to wait d before reconnecting.
+func WithSSERetry(d time.Duration) SSEOption {
+	return newSSEOption(func(s *sse) {
+		s.retry = d
+	})
+}
+
+// WithSSEFilter constructs SSEOption to deliver to the client only events fn returns true for.
+func WithSSEFilter(fn func(r *http.Request, e SSEEvent) bool) SSEOption {
+	return newSSEOption(func(s *sse) {
+		s.filter = fn
+	})
+}
+
+// sse is a handler streaming events from source to clients.
+type sse struct {
+	source       EventSource
+	heartbeat    time.Duration
+	buffer       int
+	writeTimeout time.Duration
+	retry        time.Duration
+	filter       func(r *http.Request, e SSEEvent) bool
+}
+
+// SSE returns handler streaming events of source to clients as Server-Sent Events.
+func SSE(source EventSource, opts ...SSEOption) http.Handler {
+	s := &sse{
+		source:    source,
+		heartbeat: defaultSSEHeartbeat,
+		buffer:    defaultSSEBuffer,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(s)
+		}
+	}
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *sse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId") // used by polyfills unable to set headers
+	}
+
+	events, err := s.source.Subscribe(ctx, r, lastEventID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidLastEventID) {
+			status = http.StatusBadRequest
+		}
+		RenderError(w, r, status, err)
+		return
+	}
+
+	// queue decouples source from client, source is never blocked by a slow client
+	queue := make(chan SSEEvent, s.buffer)
+	go func() {
+		defer close(queue)
+		for e := range events {
+			if s.filter != nil && !s.filter(r, e) {
+				continue
+			}
+			select {
+			case queue <- e:
+			default:
+				log.WithFields(log.Fields{"path": r.URL.Path}).WithError(ErrSlowConsumer).Warn("sse: disconnecting client")
+				cancel()
+				for range events {
+					// drain until source observes cancellation
+				}
+				return
+			}
+		}
+	}()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", ContentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if s.retry > 0 {
+		if !s.write(w, rc, []byte("retry: "+strconv.FormatInt(s.retry.Milliseconds(), 10)+"\n\n")) {
+			return
+		}
+	} else if err := rc.Flush(); err != nil {
+		return
+	}
+
+	var heartbeat <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case e, ok := <-queue:
+			if !ok || !s.write(w, rc, encodeSSE(e)) {
+				return
+			}
+		case <-heartbeat:
+			if !s.write(w, rc, []byte(": heartbeat\n\n")) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// write writes b to the client and flushes it, it reports whether write succeeded.
+func (s *sse) write(w http.ResponseWriter, rc *http.ResponseController, b []byte) bool {
+	if s.writeTimeout > 0 {
+		rc.SetWriteDeadline(time.Now().Add(s.writeTimeout)) // best effort, not every writer supports deadlines
+	}
+	if _, err := w.Write(b); err != nil {
+		return false
+	}
+	return rc.Flush() == nil
+}
+
+// encodeSSE encodes e into the event stream format.
+func encodeSSE(e SSEEvent) []byte {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		buf.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		buf.WriteString("event: " + e.Event + "\n")
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(e.Data))
+	scanner.Buffer(nil, len(e.Data)+1)
+	for scanner.Scan() {
+		buf.WriteString("data: ")
+		buf.Write(scanner.Bytes())
+		buf.WriteByte('\n')
+	}
+	if len(e.Data) == 0 {
+		buf.WriteString("data\n")
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
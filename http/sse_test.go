@@ -0,0 +1,154 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/es"
+)
+
+// TestSSE verifies events are streamed with ids, filtered and resumed from Last-Event-ID.
+func TestSSE(t *testing.T) {
+	source := EventSourceFunc(func(ctx context.Context, r *http.Request, lastEventID string) (<-chan SSEEvent, error) {
+		from := 0
+		if lastEventID != "" {
+			var err error
+			if from, err = strconv.Atoi(lastEventID); err != nil {
+				return nil, ErrInvalidLastEventID
+			}
+		}
+		ch := make(chan SSEEvent)
+		go func() {
+			defer close(ch)
+			for i := from + 1; i <= 4; i++ {
+				select {
+				case ch <- SSEEvent{ID: strconv.Itoa(i), Event: "tick", Data: []byte("line\n" + strconv.Itoa(i))}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch, nil
+	})
+
+	odd := WithSSEFilter(func(r *http.Request, e SSEEvent) bool {
+		n, _ := strconv.Atoi(e.ID)
+		return n%2 == 1
+	})
+	srv := httptest.NewServer(SSE(source, odd, WithSSEHeartbeat(time.Hour)))
+	defer srv.Close()
+
+	var testcases = []struct {
+		lastEventID string
+
+		status int
+		ids    []string
+	}{
+		{status: http.StatusOK, ids: []string{"1", "3"}},
+		{lastEventID: "1", status: http.StatusOK, ids: []string{"3"}},
+		{lastEventID: "x", status: http.StatusBadRequest},
+	}
+
+	for i, tt := range testcases {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if tt.lastEventID != "" {
+			req.Header.Set("Last-Event-ID", tt.lastEventID)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := res.StatusCode, tt.status; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+
+		var ids, data []string
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if id, ok := strings.CutPrefix(line, "id: "); ok {
+				ids = append(ids, id)
+			}
+			if d, ok := strings.CutPrefix(line, "data: "); ok {
+				data = append(data, d)
+			}
+		}
+		res.Body.Close()
+
+		if got, want := strings.Join(ids, ","), strings.Join(tt.ids, ","); got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := len(data), 2*len(tt.ids); got != want {
+			t.Errorf("#%d got %v data lines, want %v", i, got, want)
+		}
+	}
+}
+
+// TestNotifierEventSource verifies notifications are streamed as JSON events identified by their positions.
+func TestNotifierEventSource(t *testing.T) {
+	notifier := es.NotifierFunc(func(ctx context.Context, after string) (<-chan es.Notification, error) {
+		if after == "x" {
+			return nil, es.ErrInvalidPosition
+		}
+		ch := make(chan es.Notification, 2)
+		ch <- es.Notification{Position: "1", Aggregate: "order", AggregateID: "a", Version: 1, Type: "placed", Data: map[string]int{"amount": 1}}
+		ch <- es.Notification{Position: "2", Aggregate: "order", AggregateID: "a", Version: 2, Type: "paid"}
+		close(ch)
+		return ch, nil
+	})
+	source := NotifierEventSource(func(r *http.Request) es.Notifier { return notifier })
+
+	if _, err := source.Subscribe(context.Background(), httptest.NewRequest(http.MethodGet, "/", nil), "x"); !errors.Is(err, ErrInvalidLastEventID) {
+		t.Errorf("got %v, want %v", err, ErrInvalidLastEventID)
+	}
+
+	events, err := source.Subscribe(context.Background(), httptest.NewRequest(http.MethodGet, "/", nil), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testcases = []SSEEvent{
+		{ID: "1", Event: "placed", Data: []byte(`{"aggregate_id":"a","aggregate":"order","version":1,"type":"placed","timestamp":"0001-01-01T00:00:00Z","data":{"amount":1}}`)},
+		{ID: "2", Event: "paid", Data: []byte(`{"aggregate_id":"a","aggregate":"order","version":2,"type":"paid","timestamp":"0001-01-01T00:00:00Z","data":null}`)},
+	}
+
+	for i, want := range testcases {
+		got, ok := <-events
+		if !ok {
+			t.Fatalf("#%d got %v, want %v", i, ok, true)
+		}
+		if got.ID != want.ID || got.Event != want.Event || string(got.Data) != string(want.Data) {
+			t.Errorf("#%d got %+v, want %+v", i, got, want)
+		}
+	}
+	if _, ok := <-events; ok {
+		t.Errorf("got %v, want %v", ok, false)
+	}
+}
+
+// TestSSEBuffer verifies clients are always given room for at least a single event.
+func TestSSEBuffer(t *testing.T) {
+	var testcases = []struct {
+		n      int
+		buffer int
+	}{
+		{n: -1, buffer: 1},
+		{n: 0, buffer: 1},
+		{n: 5, buffer: 5},
+	}
+
+	for i, tt := range testcases {
+		s := SSE(nil, WithSSEBuffer(tt.n)).(*sse)
+		if got, want := s.buffer, tt.buffer; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// ErrRangeNotSupported is returned when download can not be resumed as server does not support ranges.
+var ErrRangeNotSupported = errors.New("server does not support range requests")
+
+// ErrRangeMismatch is returned when resumed download does not continue at offset, e.g. remote resource changed.
+var ErrRangeMismatch = errors.New("range does not match remote resource")
+
+// ProgressFunc is called as body is transferred, total is -1 if body length is unknown.
+type ProgressFunc func(transferred, total int64)
+
+// WithContentLength constructs RequestOption declaring length of streamed request body.
+func WithContentLength(n int64) RequestOption {
+	return newRequestOption(func(r *http.Request) {
+		r.ContentLength = n
+	})
+}
+
+// WithUploadProgress constructs RequestOption reporting progress of sending request body to fn.
+func WithUploadProgress(fn ProgressFunc) RequestOption {
+	return newRequestOption(func(r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			return
+		}
+		total := r.ContentLength
+		if total == 0 {
+			total = -1
+		}
+		r.Body = &progressReader{ReadCloser: r.Body, total: total, fn: fn}
+	})
+}
+
+// WithDownloadProgress constructs RequestOption reporting progress of reading response body to fn.
+func WithDownloadProgress(fn ProgressFunc) RequestOption {
+	return newRequestOption(func(r *http.Request) {
+		*r = *r.WithContext(context.WithValue(r.Context(), downloadProgressKey, fn))
+	})
+}
+
+// withDownloadProgress wraps res body reporting its progress if requested by WithDownloadProgress.
+func withDownloadProgress(req *http.Request, res *http.Response) {
+	fn, ok := req.Context().Value(downloadProgressKey).(ProgressFunc)
+	if !ok || res.Body == nil {
+		return
+	}
+	res.Body = &progressReader{ReadCloser: res.Body, total: res.ContentLength, fn: fn}
+}
+
+// WithIfRange constructs RequestOption resuming download only if remote resource still matches validator,
+// i.e. ETag or Last-Modified of the response the partial download was started from.
+// Changed resources are sent in full resulting in ErrRangeNotSupported.
+func WithIfRange(validator string) RequestOption {
+	return newRequestOption(func(r *http.Request) {
+		r.Header.Set("If-Range", validator)
+	})
+}
+
+// progressReader is io.ReadCloser reporting number of bytes read.
+type progressReader struct {
+	io.ReadCloser
+	read  int64
+	total int64
+	fn    ProgressFunc
+}
+
+// Read implements io.Reader.
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.read += int64(n)
+		r.fn(r.read, r.total)
+	}
+	return n, err
+}
+
+// Download writes body of GET uri response to w. If offset is greater than zero the download is
+// resumed by requesting remaining bytes using Range header, ErrRangeNotSupported is returned by servers
+// ignoring ranges as w already holds offset bytes, ErrRangeMismatch is returned if the remaining bytes
+// do not continue at offset. It returns number of bytes written.
+func (c *Client) Download(ctx context.Context, uri string, w io.Writer, offset int64, options ...RequestOption) (int64, error) {
+	if offset > 0 {
+		options = append(options, newRequestOption(func(r *http.Request) {
+			r.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		}))
+	}
+
+	res, err := c.send(ctx, http.MethodGet, uri, nil, options...)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusOK && offset == 0:
+	case res.StatusCode == http.StatusPartialContent && offset > 0:
+		start, _, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil {
+			return 0, err
+		}
+		if start != offset {
+			return 0, errors.Wrapf(ErrRangeMismatch, "got range starting at %d, want %d", start, offset)
+		}
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		_, size, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil {
+			return 0, err
+		}
+		if size != offset {
+			return 0, errors.Wrapf(ErrRangeMismatch, "got size %d, want %d", size, offset)
+		}
+		return 0, nil // already complete
+	case res.StatusCode == http.StatusOK:
+		return 0, ErrRangeNotSupported
+	default:
+		return 0, errors.Newf("request resulted in %d response code", res.StatusCode)
+	}
+
+	return io.Copy(w, res.Body)
+}
+
+// parseContentRange parses Content-Range header value of the form "bytes start-end/size" or "bytes */size",
+// start is -1 for the latter and size is -1 if unknown.
+func parseContentRange(v string) (start, size int64, err error) {
+	rng, ok := strings.CutPrefix(v, "bytes ")
+	if !ok {
+		return 0, 0, errors.Newf("invalid content range %q", v)
+	}
+	rng, total, ok := strings.Cut(rng, "/")
+	if !ok {
+		return 0, 0, errors.Newf("invalid content range %q", v)
+	}
+
+	size = -1
+	if total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return 0, 0, errors.Newf("invalid content range %q", v)
+		}
+	}
+
+	start = -1
+	if rng != "*" {
+		first, _, ok := strings.Cut(rng, "-")
+		if !ok {
+			return 0, 0, errors.Newf("invalid content range %q", v)
+		}
+		if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+			return 0, 0, errors.Newf("invalid content range %q", v)
+		}
+	}
+
+	return start, size, nil
+}
+
+// DownloadFile downloads body of GET uri response into file at path. Partially downloaded file
+// is resumed, it is downloaded again if server does not support ranges or the file does not match
+// the remote resource.
+func (c *Client) DownloadFile(ctx context.Context, uri string, path string, options ...RequestOption) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Download(ctx, uri, f, offset, options...)
+	if errors.Is(err, ErrRangeNotSupported) || errors.Is(err, ErrRangeMismatch) {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		_, err = c.Download(ctx, uri, f, 0, options...)
+	}
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,213 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// TestUpload verifies multipart forms are streamed and upload progress is reported.
+func TestUpload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(path, []byte("file content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(file)
+		w.Write([]byte(r.FormValue("name") + ":" + header.Filename + ":" + string(b)))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uploaded, total int64
+	form := NewMultipartForm().AddField("name", "q1").AddFile("file", path)
+	res, err := client.Upload(context.Background(), http.MethodPost, "upload", form, WithUploadProgress(func(n, t int64) {
+		uploaded, total = n, t
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if got, want := string(b), "q1:report.txt:file content"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if uploaded == 0 || total != -1 {
+		t.Errorf("got progress %d/%d, want non zero of unknown total", uploaded, total)
+	}
+}
+
+// TestDownload verifies downloads are resumed using ranges.
+func TestDownload(t *testing.T) {
+	content := strings.Repeat("0123456789", 10)
+
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var testcases = []struct {
+		partial string
+
+		rng        string
+		downloaded int64
+	}{
+		{partial: "", rng: "", downloaded: 100},
+		{partial: content[:40], rng: "bytes=40-", downloaded: 100},
+		{partial: content, rng: "bytes=100-", downloaded: 100},
+	}
+
+	for i, tt := range testcases {
+		ranges = nil
+		path := filepath.Join(t.TempDir(), "file")
+		if tt.partial != "" {
+			os.WriteFile(path, []byte(tt.partial), 0o644)
+		}
+
+		var downloaded int64
+		err := client.DownloadFile(context.Background(), "file", path, WithDownloadProgress(func(n, total int64) {
+			downloaded = int64(len(tt.partial)) + n
+		}))
+		if err != nil {
+			t.Fatalf("#%d got %v", i, err)
+		}
+
+		b, _ := os.ReadFile(path)
+		if !bytes.Equal(b, []byte(content)) {
+			t.Errorf("#%d got %v, want %v", i, string(b), content)
+		}
+		if got, want := ranges[0], tt.rng; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if tt.partial != content {
+			if got, want := downloaded, tt.downloaded; got != want {
+				t.Errorf("#%d got %v, want %v", i, got, want)
+			}
+		}
+	}
+}
+
+// TestDownloadRestart verifies downloads are restarted when they can not be resumed.
+func TestDownloadRestart(t *testing.T) {
+	content := strings.Repeat("0123456789", 10)
+
+	var testcases = []struct {
+		partial string
+		ranges  bool // whether server supports ranges
+
+		requests int
+		want     string
+	}{
+		{partial: content[:40], ranges: false, requests: 2, want: content},
+		{partial: content + "stale", ranges: true, requests: 2, want: content},
+		{partial: "9876543210", ranges: true, requests: 1, want: "9876543210" + content[10:]},
+	}
+
+	for i, tt := range testcases {
+		var requests int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if !tt.ranges {
+				io.WriteString(w, content)
+				return
+			}
+			http.ServeContent(w, r, "file", time.Time{}, strings.NewReader(content))
+		}))
+
+		client, err := NewClient(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(t.TempDir(), "file")
+		os.WriteFile(path, []byte(tt.partial), 0o644)
+
+		err = client.DownloadFile(context.Background(), "file", path)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("#%d got %v", i, err)
+		}
+
+		b, _ := os.ReadFile(path)
+		if got, want := string(b), tt.want; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := requests, tt.requests; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestDownloadRange verifies resumed downloads are checked against the remote resource.
+func TestDownloadRange(t *testing.T) {
+	var testcases = []struct {
+		status       int
+		contentRange string
+		ifRange      string
+
+		err error
+	}{
+		{status: http.StatusPartialContent, contentRange: "bytes 40-99/100"},
+		{status: http.StatusPartialContent, contentRange: "bytes 0-99/100", err: ErrRangeMismatch},
+		{status: http.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */40"},
+		{status: http.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */30", err: ErrRangeMismatch},
+		{status: http.StatusOK, ifRange: `"v1"`, err: ErrRangeNotSupported},
+	}
+
+	for i, tt := range testcases {
+		var ifRange string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ifRange = r.Header.Get("If-Range")
+			if tt.contentRange != "" {
+				w.Header().Set("Content-Range", tt.contentRange)
+			}
+			w.WriteHeader(tt.status)
+		}))
+
+		client, err := NewClient(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var options []RequestOption
+		if tt.ifRange != "" {
+			options = append(options, WithIfRange(tt.ifRange))
+		}
+
+		_, err = client.Download(context.Background(), "file", io.Discard, 40, options...)
+		srv.Close()
+		if !errors.Is(err, tt.err) {
+			t.Errorf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if got, want := ifRange, tt.ifRange; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
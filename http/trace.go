@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/deividaspetraitis/go/trace"
+
+	"github.com/gorilla/mux"
+)
+
+// traced is a middleware continuing trace propagated by W3C traceparent header
+// and recording a server span for each request.
+func traced(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				name = tpl
+			}
+		}
+
+		ctx := trace.Extract(r.Context(), r.Header)
+		ctx, span := trace.Start(ctx, r.Method+" "+name,
+			trace.WithKind(trace.KindServer),
+			trace.WithAttribute("http.method", r.Method),
+			trace.WithAttribute("http.route", name),
+		)
+		defer span.Finish()
+
+		sw := newStatusWriter(w)
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", strconv.Itoa(sw.Status()))
+	})
+}
@@ -0,0 +1,248 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// TransportOption is modifier of a transport constructed by NewTransport.
+type TransportOption interface {
+	apply(*transportConfig)
+}
+
+// newTransportOption constructs a new transportOption.
+func newTransportOption(fn func(c *transportConfig)) *transportOption {
+	return &transportOption{applyFn: fn}
+}
+
+// transportOption is an implementation of TransportOption.
+type transportOption struct {
+	applyFn func(c *transportConfig)
+}
+
+// apply implements TransportOption.
+func (o *transportOption) apply(c *transportConfig) {
+	o.applyFn(c)
+}
+
+// transportConfig holds settings of a transport being constructed.
+type transportConfig struct {
+	transport *http.Transport
+	dialer    *net.Dialer
+	tls       *tls.Config
+	caFiles   []string
+	caCerts   [][]byte
+	certFile  string
+	keyFile   string
+	proxy     string
+	http2     bool
+}
+
+// WithDialTimeout constructs TransportOption limiting time establishing a connection is allowed to take.
+func WithDialTimeout(d time.Duration) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.dialer.Timeout = d
+	})
+}
+
+// WithKeepAlive constructs TransportOption setting interval of TCP keep alive probes.
+func WithKeepAlive(d time.Duration) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.dialer.KeepAlive = d
+	})
+}
+
+// WithTLSHandshakeTimeout constructs TransportOption limiting time TLS handshake is allowed to take.
+func WithTLSHandshakeTimeout(d time.Duration) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.transport.TLSHandshakeTimeout = d
+	})
+}
+
+// WithResponseHeaderTimeout constructs TransportOption limiting time to wait for response headers once request is written.
+func WithResponseHeaderTimeout(d time.Duration) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.transport.ResponseHeaderTimeout = d
+	})
+}
+
+// WithIdleConnTimeout constructs TransportOption limiting time idle connection is kept in the pool.
+func WithIdleConnTimeout(d time.Duration) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.transport.IdleConnTimeout = d
+	})
+}
+
+// WithMaxIdleConns constructs TransportOption limiting number of idle connections in total and per host.
+func WithMaxIdleConns(total, perHost int) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.transport.MaxIdleConns = total
+		c.transport.MaxIdleConnsPerHost = perHost
+	})
+}
+
+// WithMaxConnsPerHost constructs TransportOption limiting number of connections per host, zero means no limit.
+func WithMaxConnsPerHost(n int) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.transport.MaxConnsPerHost = n
+	})
+}
+
+// WithTLSConfig constructs TransportOption to use cfg as a base TLS configuration.
+func WithTLSConfig(cfg *tls.Config) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.tls = cfg.Clone()
+	})
+}
+
+// WithCAFile constructs TransportOption to trust certificate authorities from PEM encoded file at path
+// instead of system certificate pool.
+func WithCAFile(path string) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.caFiles = append(c.caFiles, path)
+	})
+}
+
+// WithCACert constructs TransportOption to trust PEM encoded certificate authorities
+// instead of system certificate pool.
+func WithCACert(pem []byte) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.caCerts = append(c.caCerts, pem)
+	})
+}
+
+// WithClientCertificate constructs TransportOption to present certificate loaded from PEM encoded
+// files to servers requiring mutual TLS.
+func WithClientCertificate(certFile, keyFile string) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.certFile, c.keyFile = certFile, keyFile
+	})
+}
+
+// WithProxy constructs TransportOption to send requests through proxy at rawURL.
+// Empty rawURL disables proxying, by default proxy is taken from environment variables.
+func WithProxy(rawURL string) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.proxy = rawURL
+		c.transport.Proxy = nil
+	})
+}
+
+// WithHTTP2 constructs TransportOption enabling or disabling HTTP/2, it is enabled by default.
+func WithHTTP2(enabled bool) TransportOption {
+	return newTransportOption(func(c *transportConfig) {
+		c.http2 = enabled
+	})
+}
+
+// NewTransport constructs http.Transport based on http.DefaultTransport settings modified by opts.
+func NewTransport(opts ...TransportOption) (*http.Transport, error) {
+	c := &transportConfig{
+		transport: http.DefaultTransport.(*http.Transport).Clone(),
+		dialer: &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+		http2: true,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(c)
+		}
+	}
+
+	t := c.transport
+	t.DialContext = c.dialer.DialContext
+
+	if c.proxy != "" {
+		u, err := url.Parse(c.proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing proxy URL")
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	cfg := c.tls
+	if cfg == nil {
+		cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if len(c.caFiles) > 0 || len(c.caCerts) > 0 {
+		pool := x509.NewCertPool()
+		for _, path := range c.caFiles {
+			pem, err := os.ReadFile(path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading CA file %s", path)
+			}
+			c.caCerts = append(c.caCerts, pem)
+		}
+		for _, pem := range c.caCerts {
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.New("no valid CA certificates found")
+			}
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.certFile != "" || c.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading client certificate")
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+	}
+
+	t.TLSClientConfig = cfg
+	t.ForceAttemptHTTP2 = c.http2
+	if !c.http2 {
+		// non nil empty map disables HTTP/2 upgrade
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	return t, nil
+}
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as http.RoundTripper.
+type RoundTripperFunc func(r *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TransportMiddleware wraps http.RoundTripper adding cross-cutting behaviour.
+type TransportMiddleware func(next http.RoundTripper) http.RoundTripper
+
+// Chain wraps rt with middlewares, the first middleware is the outermost one.
+func Chain(rt http.RoundTripper, middlewares ...TransportMiddleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if middlewares[i] != nil {
+			rt = middlewares[i](rt)
+		}
+	}
+	return rt
+}
+
+// Use wraps client transport with middlewares, the first middleware is the outermost one.
+func (c *Client) Use(middlewares ...TransportMiddleware) {
+	rt := c.http.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	c.SetTransport(Chain(rt, middlewares...))
+}
+
+// SetTimeout limits time a single request including reading response body is allowed to take.
+// Zero means no timeout.
+func (c *Client) SetTimeout(d time.Duration) {
+	hc := *c.http
+	hc.Timeout = d
+	c.http = &hc
+}
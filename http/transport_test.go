@@ -0,0 +1,64 @@
+package http
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestTransport verifies custom CA trust and middleware chain order.
+func TestTransport(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Chain")))
+	}))
+	defer srv.Close()
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	var testcases = []struct {
+		opts []TransportOption
+		ok   bool
+	}{
+		{opts: nil, ok: false},
+		{opts: []TransportOption{WithCACert(ca), WithHTTP2(false)}, ok: true},
+	}
+
+	for i, tt := range testcases {
+		transport, err := NewTransport(tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client, err := NewClient(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.SetTransport(transport)
+
+		var order []string
+		tag := func(name string) TransportMiddleware {
+			return func(next http.RoundTripper) http.RoundTripper {
+				return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+					order = append(order, name)
+					r.Header.Add("X-Chain", name)
+					return next.RoundTrip(r)
+				})
+			}
+		}
+		client.Use(tag("a"), tag("b"))
+
+		res, err := client.Request(context.Background(), http.MethodGet, "", nil)
+		if got, want := err == nil, tt.ok; got != want {
+			t.Fatalf("#%d got %v (%v), want %v", i, got, err, want)
+		}
+		if got, want := strings.Join(order, ","), "a,b"; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if err == nil {
+			res.Body.Close()
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/es"
+	"github.com/deividaspetraitis/go/log"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket message types.
+const (
+	WSMessageEvent       = "event"       // server: event published to a topic
+	WSMessageSubscribe   = "subscribe"   // client: subscribe to a topic
+	WSMessageUnsubscribe = "unsubscribe" // client: unsubscribe from a topic
+	WSMessageError       = "error"       // server: client message could not be handled
+)
+
+const (
+	defaultWSSendBuffer   = 256              // default number of messages queued per connection
+	defaultWSPingInterval = 30 * time.Second // default interval of keep alive pings
+	defaultWSPongTimeout  = 60 * time.Second // default time to wait for any message including pong
+	defaultWSWriteTimeout = 10 * time.Second // default time limit of a single write
+	defaultWSReadLimit    = 64 << 10         // default maximum size of a client message
+)
+
+var (
+	// ErrHubClosed is returned when publishing to or connecting to closed Hub.
+	ErrHubClosed = errors.New("hub closed")
+
+	// ErrUnsupportedMessage is sent to clients sending messages of unknown type.
+	ErrUnsupportedMessage = errors.New("unsupported message type")
+)
+
+// WSMessage is a JSON frame exchanged over WebSocket connections.
+type WSMessage struct {
+	Type  string          `json:"type"`
+	Topic string          `json:"topic,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// HubOption is modifier of a Hub.
+type HubOption interface {
+	apply(*Hub)
+}
+
+// newHubOption constructs a new hubOption.
+func newHubOption(fn func(h *Hub)) *hubOption {
+	return &hubOption{applyFn: fn}
+}
+
+// hubOption is an implementation of HubOption.
+type hubOption struct {
+	applyFn func(h *Hub)
+}
+
+// apply implements HubOption.
+func (o *hubOption) apply(h *Hub) {
+	o.applyFn(h)
+}
+
+// WithHubSendBuffer constructs HubOption to queue up to n messages per connection.
+// Connections falling behind by more than n messages are evicted.
+func WithHubSendBuffer(n int) HubOption {
+	return newHubOption(func(h *Hub) {
+		h.sendBuffer = n
+	})
+}
+
+// WithHubKeepalive constructs HubOption to ping connections every interval and
+// to close connections silent for longer than timeout. Non positive interval disables
+// pings, non positive timeout keeps silent connections open.
+func WithHubKeepalive(interval, timeout time.Duration) HubOption {
+	return newHubOption(func(h *Hub) {
+		h.pingInterval, h.pongTimeout = interval, timeout
+	})
+}
+
+// WithHubWriteTimeout constructs HubOption limiting time a single write is allowed to take.
+func WithHubWriteTimeout(d time.Duration) HubOption {
+	return newHubOption(func(h *Hub) {
+		h.writeTimeout = d
+	})
+}
+
+// WithHubReadLimit constructs HubOption limiting size of client messages to n bytes.
+func WithHubReadLimit(n int64) HubOption {
+	return newHubOption(func(h *Hub) {
+		h.readLimit = n
+	})
+}
+
+// WithHubCheckOrigin constructs HubOption to accept connections only from origins fn returns true for.
+// By default only same origin connections are accepted.
+func WithHubCheckOrigin(fn func(r *http.Request) bool) HubOption {
+	return newHubOption(func(h *Hub) {
+		h.upgrader.CheckOrigin = fn
+	})
+}
+
+// WithHubAuthorizer constructs HubOption to authorize topic subscriptions, non nil error denies subscription.
+// Hub without authorizer denies every subscription.
+func WithHubAuthorizer(fn func(r *http.Request, topic string) error) HubOption {
+	return newHubOption(func(h *Hub) {
+		h.authorize = fn
+	})
+}
+
+// WithHubHandler constructs HubOption to handle client messages other than subscribe and unsubscribe.
+func WithHubHandler(fn func(c *WSConn, m WSMessage)) HubOption {
+	return newHubOption(func(h *Hub) {
+		h.handler = fn
+	})
+}
+
+// Hub manages WebSocket connections and broadcasts messages to connections subscribed to topics.
+type Hub struct {
+	upgrader     websocket.Upgrader
+	sendBuffer   int
+	pingInterval time.Duration
+	pongTimeout  time.Duration
+	writeTimeout time.Duration
+	readLimit    int64
+	authorize    func(r *http.Request, topic string) error
+	handler      func(c *WSConn, m WSMessage)
+
+	mu     sync.RWMutex // guard fields below
+	conns  map[*WSConn]struct{}
+	topics map[string]map[*WSConn]struct{}
+	closed bool
+}
+
+// NewHub constructs a new Hub.
+func NewHub(opts ...HubOption) *Hub {
+	h := &Hub{
+		sendBuffer:   defaultWSSendBuffer,
+		pingInterval: defaultWSPingInterval,
+		pongTimeout:  defaultWSPongTimeout,
+		writeTimeout: defaultWSWriteTimeout,
+		readLimit:    defaultWSReadLimit,
+		conns:        make(map[*WSConn]struct{}),
+		topics:       make(map[string]map[*WSConn]struct{}),
+	}
+	h.upgrader.Error = func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		RenderError(w, r, status, reason)
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(h)
+		}
+	}
+	return h
+}
+
+// HandleWebSocket registers hub accepting WebSocket connections at path.
+func (a *App) HandleWebSocket(path string, hub *Hub) *mux.Route {
+	return a.API.Handle(path, hub).Methods(http.MethodGet)
+}
+
+// ServeHTTP implements http.Handler upgrading request to WebSocket connection.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		RenderError(w, r, http.StatusServiceUnavailable, ErrHubClosed)
+		return
+	}
+
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // upgrader already replied with an error
+	}
+
+	c := &WSConn{
+		hub:     h,
+		ws:      ws,
+		request: r,
+		send:    make(chan []byte, h.sendBuffer),
+		done:    make(chan struct{}),
+		topics:  make(map[string]struct{}),
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		ws.Close()
+		return
+	}
+	h.conns[c] = struct{}{}
+	h.mu.Unlock()
+
+	go c.writeLoop()
+	c.readLoop()
+}
+
+// Publish marshals v as JSON and sends it to every connection subscribed to topic.
+func (h *Hub) Publish(topic string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	msg, err := json.Marshal(WSMessage{Type: WSMessageEvent, Topic: topic, Data: data})
+	if err != nil {
+		return err
+	}
+
+	h.mu.RLock()
+	if h.closed {
+		h.mu.RUnlock()
+		return ErrHubClosed
+	}
+	conns := make([]*WSConn, 0, len(h.topics[topic]))
+	for c := range h.topics[topic] {
+		conns = append(conns, c)
+	}
+	h.mu.RUnlock()
+
+	for _, c := range conns {
+		c.enqueue(msg)
+	}
+
+	return nil
+}
+
+// Subscribe subscribes c to topic.
+func (h *Hub) Subscribe(c *WSConn, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.conns[c]; !ok {
+		return // connection is already closed
+	}
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[*WSConn]struct{})
+	}
+	h.topics[topic][c] = struct{}{}
+	c.topics[topic] = struct{}{}
+}
+
+// Unsubscribe unsubscribes c from topic.
+func (h *Hub) Unsubscribe(c *WSConn, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unsubscribe(c, topic)
+}
+
+// unsubscribe unsubscribes c from topic, h.mu must be held.
+func (h *Hub) unsubscribe(c *WSConn, topic string) {
+	delete(c.topics, topic)
+	delete(h.topics[topic], c)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+// Subscribers returns number of connections subscribed to topic.
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Close closes all connections, closed Hub does not accept new connections.
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	h.closed = true
+	conns := make([]*WSConn, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.close(websocket.CloseGoingAway, ErrHubClosed)
+	}
+	return nil
+}
+
+// Bridge publishes notifications about events committed after the call to topics returned by topics
+// until ctx is done or notifier stops. If topics is nil notifications are published to aggregate and
+// aggregate/id topics, ie. "Order" and "Order/123". ErrHubClosed is returned once hub is closed.
+func (h *Hub) Bridge(ctx context.Context, notifier es.Notifier, topics func(n es.Notification) []string) error {
+	if topics == nil {
+		topics = func(n es.Notification) []string {
+			return []string{n.Aggregate, n.Aggregate + "/" + n.AggregateID}
+		}
+	}
+
+	notifications, err := notifier.Notify(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	for n := range notifications {
+		for _, topic := range topics(n) {
+			err := h.Publish(topic, n)
+			if errors.Is(err, ErrHubClosed) {
+				return err
+			}
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{"topic": topic}).Error("websocket: unable to publish notification")
+			}
+		}
+	}
+
+	return nil
+}
+
+// authorizeSubscription authorizes subscription of request r to topic, subscriptions are denied unless authorizer is set.
+func (h *Hub) authorizeSubscription(r *http.Request, topic string) error {
+	if h.authorize == nil {
+		return ErrForbidden
+	}
+	return h.authorize(r, topic)
+}
+
+// remove removes c along with its subscriptions.
+func (h *Hub) remove(c *WSConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for topic := range c.topics {
+		h.unsubscribe(c, topic)
+	}
+	delete(h.conns, c)
+}
+
+// WSConn is a single WebSocket connection managed by Hub.
+type WSConn struct {
+	hub     *Hub
+	ws      *websocket.Conn
+	request *http.Request
+	send    chan []byte
+	done    chan struct{}
+	once    sync.Once
+	topics  map[string]struct{} // guarded by hub.mu
+}
+
+// Request returns request connection was upgraded from.
+func (c *WSConn) Request() *http.Request {
+	return c.request
+}
+
+// Send queues m to be sent to the connection.
+func (c *WSConn) Send(m WSMessage) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if !c.enqueue(b) {
+		return errors.New("connection closed")
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (c *WSConn) Close() error {
+	c.close(websocket.CloseNormalClosure, nil)
+	return nil
+}
+
+// enqueue queues b without blocking, connection is evicted if its queue is full.
+func (c *WSConn) enqueue(b []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	select {
+	case c.send <- b:
+		return true
+	default:
+		log.WithFields(log.Fields{"remote_addr": c.request.RemoteAddr}).WithError(ErrSlowConsumer).Warn("websocket: evicting connection")
+		c.close(websocket.CloseTryAgainLater, ErrSlowConsumer)
+		return false
+	}
+}
+
+// close closes the connection with code and reason once.
+func (c *WSConn) close(code int, reason error) {
+	c.once.Do(func() {
+		close(c.done)
+		c.hub.remove(c)
+
+		text := ""
+		if reason != nil {
+			text = reason.Error()
+		}
+		c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(c.hub.writeTimeout))
+		c.ws.Close()
+	})
+}
+
+// readDeadline returns deadline of reading the next client message or pong,
+// zero time is returned if silent connections are kept open.
+func (h *Hub) readDeadline() time.Time {
+	if h.pongTimeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(h.pongTimeout)
+}
+
+// readLoop reads and handles client messages until connection fails.
+func (c *WSConn) readLoop() {
+	defer c.close(websocket.CloseNormalClosure, nil)
+
+	c.ws.SetReadLimit(c.hub.readLimit)
+	c.ws.SetReadDeadline(c.hub.readDeadline())
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(c.hub.readDeadline())
+	})
+
+	for {
+		_, b, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.ws.SetReadDeadline(c.hub.readDeadline())
+
+		var m WSMessage
+		if err := json.Unmarshal(b, &m); err != nil {
+			c.Send(WSMessage{Type: WSMessageError, Data: errorData(err)})
+			continue
+		}
+
+		switch m.Type {
+		case WSMessageSubscribe:
+			if err := c.hub.authorizeSubscription(c.request, m.Topic); err != nil {
+				c.Send(WSMessage{Type: WSMessageError, Topic: m.Topic, Data: errorData(err)})
+				continue
+			}
+			c.hub.Subscribe(c, m.Topic)
+		case WSMessageUnsubscribe:
+			c.hub.Unsubscribe(c, m.Topic)
+		default:
+			if c.hub.handler == nil {
+				c.Send(WSMessage{Type: WSMessageError, Topic: m.Topic, Data: errorData(ErrUnsupportedMessage)})
+				continue
+			}
+			c.hub.handler(c, m)
+		}
+	}
+}
+
+// writeLoop writes queued messages and keep alive pings until connection is closed.
+func (c *WSConn) writeLoop() {
+	var ping <-chan time.Time // nil blocks forever if pings are disabled
+	if c.hub.pingInterval > 0 {
+		ticker := time.NewTicker(c.hub.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case b := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(c.hub.writeTimeout))
+			if err := c.ws.WriteMessage(websocket.TextMessage, b); err != nil {
+				c.close(websocket.CloseGoingAway, err)
+				return
+			}
+		case <-ping:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.hub.writeTimeout)); err != nil {
+				c.close(websocket.CloseGoingAway, err)
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// errorData returns err encoded as WSMessage data.
+func errorData(err error) json.RawMessage {
+	b, _ := json.Marshal(ErrorResponse{Error: err.Error()})
+	return b
+}
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/es"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestHub verifies topic subscriptions, broadcasting and message framing.
+func TestHub(t *testing.T) {
+	hub := NewHub(WithHubAuthorizer(func(r *http.Request, topic string) error {
+		if topic == "private" {
+			return ErrForbidden
+		}
+		return nil
+	}))
+	defer hub.Close()
+
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+
+	for _, m := range []WSMessage{
+		{Type: WSMessageSubscribe, Topic: "orders"},
+		{Type: WSMessageSubscribe, Topic: "private"},
+		{Type: "unknown"},
+	} {
+		if err := ws.WriteJSON(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var testcases = []struct {
+		typ   string
+		topic string
+		data  string
+	}{
+		{typ: WSMessageError, topic: "private", data: ErrForbidden.Error()},
+		{typ: WSMessageError, data: ErrUnsupportedMessage.Error()},
+		{typ: WSMessageEvent, topic: "orders", data: `{"id":"1"}`},
+	}
+
+	for i, tt := range testcases {
+		if tt.typ == WSMessageEvent {
+			for hub.Subscribers("orders") == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			if err := hub.Publish("orders", map[string]string{"id": "1"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var m WSMessage
+		if err := ws.ReadJSON(&m); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := m.Type, tt.typ; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if got, want := m.Topic, tt.topic; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+		if tt.typ == WSMessageError {
+			var res ErrorResponse
+			json.Unmarshal(m.Data, &res)
+			if got, want := res.Error, tt.data; got != want {
+				t.Errorf("#%d got %v, want %v", i, got, want)
+			}
+		} else if got, want := string(m.Data), tt.data; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+
+	hub.Close()
+	if err := hub.Publish("orders", nil); !errors.Is(err, ErrHubClosed) {
+		t.Errorf("got %v, want %v", err, ErrHubClosed)
+	}
+}
+
+// TestHubDenyByDefault verifies subscriptions are denied unless hub has an authorizer.
+func TestHubDenyByDefault(t *testing.T) {
+	hub := NewHub()
+	defer hub.Close()
+
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+
+	if err := ws.WriteJSON(WSMessage{Type: WSMessageSubscribe, Topic: "orders"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var m WSMessage
+	if err := ws.ReadJSON(&m); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Type, WSMessageError; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := hub.Subscribers("orders"), 0; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestHubBridge verifies notifications are published to aggregate topics.
+func TestHubBridge(t *testing.T) {
+	hub := NewHub(WithHubAuthorizer(func(r *http.Request, topic string) error { return nil }))
+
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+
+	if err := ws.WriteJSON(WSMessage{Type: WSMessageSubscribe, Topic: "order/1"}); err != nil {
+		t.Fatal(err)
+	}
+	for hub.Subscribers("order/1") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	notifications := make(chan es.Notification, 2)
+	notifications <- es.Notification{Aggregate: "order", AggregateID: "2", Type: "placed"}
+	notifications <- es.Notification{Aggregate: "order", AggregateID: "1", Type: "paid"}
+	close(notifications)
+
+	notifier := es.NotifierFunc(func(ctx context.Context, after string) (<-chan es.Notification, error) {
+		return notifications, nil
+	})
+	if err := hub.Bridge(context.Background(), notifier, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var m WSMessage
+	if err := ws.ReadJSON(&m); err != nil {
+		t.Fatal(err)
+	}
+	var n es.Notification
+	if err := json.Unmarshal(m.Data, &n); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Topic+" "+n.Type, "order/1 paid"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	hub.Close()
+	if err := hub.Bridge(context.Background(), es.NotifierFunc(func(ctx context.Context, after string) (<-chan es.Notification, error) {
+		ch := make(chan es.Notification, 1)
+		ch <- es.Notification{Aggregate: "order", AggregateID: "1"}
+		close(ch)
+		return ch, nil
+	}), nil); !errors.Is(err, ErrHubClosed) {
+		t.Errorf("got %v, want %v", err, ErrHubClosed)
+	}
+}
+
+// TestHubKeepaliveDisabled verifies connections are served with pings or pong timeout disabled.
+func TestHubKeepaliveDisabled(t *testing.T) {
+	var testcases = []struct {
+		interval time.Duration
+		timeout  time.Duration
+	}{
+		{interval: 0, timeout: time.Minute},
+		{interval: 10 * time.Millisecond, timeout: 0},
+		{interval: 0, timeout: 0},
+	}
+
+	for i, tt := range testcases {
+		hub := NewHub(
+			WithHubAuthorizer(func(r *http.Request, topic string) error { return nil }),
+			WithHubKeepalive(tt.interval, tt.timeout),
+		)
+		srv := httptest.NewServer(hub)
+
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ws.SetReadDeadline(time.Now().Add(time.Second))
+
+		if err := ws.WriteJSON(WSMessage{Type: WSMessageSubscribe, Topic: "orders"}); err != nil {
+			t.Fatal(err)
+		}
+		for hub.Subscribers("orders") == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		if err := hub.Publish("orders", map[string]string{"id": "1"}); err != nil {
+			t.Fatal(err)
+		}
+		var m WSMessage
+		if err := ws.ReadJSON(&m); err != nil {
+			t.Errorf("#%d got %v, want %v", i, err, nil)
+		} else if got, want := m.Type, WSMessageEvent; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+
+		ws.Close()
+		srv.Close()
+		hub.Close()
+	}
+}
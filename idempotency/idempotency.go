@@ -0,0 +1,47 @@
+// Package idempotency defines persistence of idempotent requests shared by
+// HTTP middleware and storage drivers.
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// ErrRecordNotFound is returned by Store when record does not exist.
+var ErrRecordNotFound = errors.New("idempotency record not found")
+
+// Response is a stored response replayed to retried requests.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record represents state of a request identified by idempotency key.
+type Record struct {
+	Key         string
+	Fingerprint string    // Request fingerprint
+	Response    *Response // Stored response, nil while request is in progress
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Store is any type capable to persist idempotency records.
+type Store interface {
+	// Begin atomically creates in-progress record expiring after ttl unless
+	// a non expired record exists, in which case such record is returned and
+	// created is false.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (rec *Record, created bool, err error)
+
+	// Complete stores response of in-progress record and extends its lifetime to ttl.
+	Complete(ctx context.Context, key string, res *Response, ttl time.Duration) error
+
+	// Release removes in-progress record allowing request to be retried.
+	Release(ctx context.Context, key string) error
+
+	// Get returns non expired record or ErrRecordNotFound.
+	Get(ctx context.Context, key string) (*Record, error)
+}
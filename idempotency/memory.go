@@ -0,0 +1,108 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultSweepInterval is the default interval of removing expired records.
+const defaultSweepInterval = time.Minute
+
+// MemoryStore is in-memory Store, expired records are swept lazily.
+type MemoryStore struct {
+	mu      sync.Mutex // guard fields below
+	records map[string]*Record
+	swept   time.Time
+
+	// Returns the current time. Defaults to time.Now()
+	// Can be mocked for tests
+	Now func() time.Time
+}
+
+// NewMemoryStore constructs and returns new MemoryStore instance.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*Record),
+		Now:     time.Now,
+	}
+}
+
+// Begin implements Store.
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Now()
+	s.sweep(now, defaultSweepInterval)
+
+	if rec, ok := s.records[key]; ok && !now.After(rec.ExpiresAt) {
+		cp := *rec
+		return &cp, false, nil
+	}
+
+	rec := &Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	s.records[key] = rec
+
+	cp := *rec
+	return &cp, true, nil
+}
+
+// Complete implements Store.
+func (s *MemoryStore) Complete(ctx context.Context, key string, res *Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[key]
+	if !ok {
+		return ErrRecordNotFound
+	}
+	rec.Response, rec.ExpiresAt = res, s.Now().Add(ttl)
+
+	return nil
+}
+
+// Release implements Store.
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[key]; ok && rec.Response == nil {
+		delete(s.records, key)
+	}
+
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[key]
+	if !ok || s.Now().After(rec.ExpiresAt) {
+		return nil, ErrRecordNotFound
+	}
+
+	cp := *rec
+	return &cp, nil
+}
+
+// sweep removes expired records at most once per interval.
+// Caller must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time, interval time.Duration) {
+	if now.Sub(s.swept) < interval {
+		return
+	}
+	for k, v := range s.records {
+		if now.After(v.ExpiresAt) {
+			delete(s.records, k)
+		}
+	}
+	s.swept = now
+}
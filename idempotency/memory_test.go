@@ -0,0 +1,89 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// TestMemoryStore verifies record lifecycle and expiry.
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Unix(1000, 0)
+	s := NewMemoryStore()
+	s.Now = func() time.Time { return now }
+
+	// in-progress record blocks key
+	if _, created, err := s.Begin(ctx, "a", "fp", time.Minute); err != nil || !created {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	rec, created, err := s.Begin(ctx, "a", "other", time.Minute)
+	if err != nil || created || rec.Fingerprint != "fp" || rec.Response != nil {
+		t.Fatalf("got %+v, want %v", rec, "in-progress record")
+	}
+
+	// released record can be begun again
+	if err := s.Release(ctx, "a"); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if _, created, _ := s.Begin(ctx, "a", "fp", time.Minute); !created {
+		t.Fatalf("got %v, want %v", created, true)
+	}
+
+	// completed record survives release and extends its lifetime
+	if err := s.Complete(ctx, "a", &Response{Status: 201}, time.Hour); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	s.Release(ctx, "a")
+
+	now = now.Add(30 * time.Minute)
+	if rec, err := s.Get(ctx, "a"); err != nil || rec.Response == nil || rec.Response.Status != 201 {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	// expired record is not found and key can be begun again
+	now = now.Add(time.Hour)
+	if _, err := s.Get(ctx, "a"); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("got %v, want %v", err, ErrRecordNotFound)
+	}
+	if _, created, _ := s.Begin(ctx, "a", "fp", time.Minute); !created {
+		t.Fatalf("got %v, want %v", created, true)
+	}
+
+	if err := s.Complete(ctx, "missing", &Response{}, time.Hour); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("got %v, want %v", err, ErrRecordNotFound)
+	}
+}
+
+// TestMemoryStoreSweep verifies expired records are swept at most once per interval.
+func TestMemoryStoreSweep(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Unix(1000, 0)
+	s := NewMemoryStore()
+	s.Now = func() time.Time { return now }
+
+	s.Begin(ctx, "a", "fp", time.Second)
+
+	var testcases = []struct {
+		elapsed time.Duration
+		records int
+	}{
+		{elapsed: 2 * time.Second, records: 2},      // "a" expired, swept recently
+		{elapsed: defaultSweepInterval, records: 1}, // "a" and "b" swept
+		{elapsed: 2 * time.Second, records: 2},      // "c" expired, swept recently
+	}
+
+	keys := []string{"b", "c", "d"}
+	for i, tt := range testcases {
+		now = now.Add(tt.elapsed)
+		s.Begin(ctx, keys[i], "fp", time.Second)
+
+		if got, want := len(s.records), tt.records; got != want {
+			t.Errorf("#%d got %v, want %v", i, got, want)
+		}
+	}
+}
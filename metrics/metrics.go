@@ -0,0 +1,282 @@
+// Package metrics implements a minimal metrics registry exposing collected
+// metrics in Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/deividaspetraitis/go/errors"
+	"github.com/deividaspetraitis/go/log"
+)
+
+// ContentType is the content type of Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Type is a metric type.
+type Type string
+
+// Supported metric types.
+const (
+	CounterType   Type = "counter"
+	GaugeType     Type = "gauge"
+	HistogramType Type = "histogram"
+)
+
+// Label is a single metric label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single metric value.
+type Sample struct {
+	Name   string  // Sample name, it may differ from family name, ie. for histogram buckets
+	Labels []Label // Sample labels
+	Value  float64 // Sample value
+}
+
+// Family represents a group of samples of the same metric.
+type Family struct {
+	Name    string
+	Help    string
+	Type    Type
+	Samples []Sample
+}
+
+// Collector is any type capable to collect metrics.
+type Collector interface {
+	Collect() []Family
+}
+
+// CollectorFunc is an adapter to allow the use of ordinary functions as Collector.
+type CollectorFunc func() []Family
+
+// Collect implements Collector.
+func (f CollectorFunc) Collect() []Family {
+	return f()
+}
+
+// Default is a default registry instance.
+var Default = NewRegistry()
+
+// Register registers collectors within Default registry.
+func Register(c ...Collector) {
+	Default.Register(c...)
+}
+
+// Handler returns http.Handler exposing metrics of Default registry.
+func Handler() http.Handler {
+	return Default
+}
+
+// Registry holds registered collectors.
+type Registry struct {
+	mu         sync.Mutex // guard fields below
+	collectors []Collector
+}
+
+// NewRegistry constructs and returns new Registry instance.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register registers collectors within registry.
+func (r *Registry) Register(c ...Collector) {
+	r.mu.Lock()
+	r.collectors = append(r.collectors, c...)
+	r.mu.Unlock()
+}
+
+// Gather collects metrics from all registered collectors.
+// Families are sorted by their names.
+func (r *Registry) Gather() []Family {
+	r.mu.Lock()
+	collectors := r.collectors
+	r.mu.Unlock()
+
+	var families []Family
+	for _, c := range collectors {
+		families = append(families, c.Collect()...)
+	}
+
+	sort.SliceStable(families, func(i, j int) bool {
+		return families[i].Name < families[j].Name
+	})
+
+	return families
+}
+
+// WriteTo writes metrics to w in Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range r.Gather() {
+		if err := writeFamily(bw, f); err != nil {
+			return cw.n, err
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP implements http.Handler.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.WriteTo(w)
+}
+
+// writeFamily writes a single family to w.
+func writeFamily(w *bufio.Writer, f Family) error {
+	if len(f.Samples) == 0 {
+		return nil
+	}
+
+	if f.Help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", f.Name, escape(f.Help, false))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.Name, f.Type)
+
+	for _, s := range f.Samples {
+		name := s.Name
+		if name == "" {
+			name = f.Name
+		}
+		w.WriteString(name)
+		if len(s.Labels) > 0 {
+			w.WriteByte('{')
+			for i, l := range s.Labels {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				fmt.Fprintf(w, `%s="%s"`, l.Name, escape(l.Value, true))
+			}
+			w.WriteByte('}')
+		}
+		w.WriteByte(' ')
+		w.WriteString(formatFloat(s.Value))
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// escape escapes s according to text format rules.
+func escape(s string, quote bool) string {
+	r := strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	if quote {
+		r = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	}
+	return r.Replace(s)
+}
+
+// formatFloat formats v according to text format rules.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countWriter counts bytes written to underlying writer.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer.
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ErrLabelsMismatch is logged when number of label values does not match number of label names.
+var ErrLabelsMismatch = errors.New("metrics: label values do not match label names")
+
+// vec holds series of a single metric partitioned by label values.
+type vec[T any] struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex // guard fields below
+	series map[string]*T
+	values map[string][]string
+	init   func() *T
+}
+
+// newVec constructs a new vec.
+func newVec[T any](name, help string, labels []string, init func() *T) vec[T] {
+	return vec[T]{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]*T),
+		values: make(map[string][]string),
+		init:   init,
+	}
+}
+
+// with returns series for given label values creating it if needed.
+// Mismatching label values are logged and nil is returned, observation is then dropped.
+// Caller must hold v.mu.
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		log.WithError(ErrLabelsMismatch).WithFields(log.Fields{
+			"metric": v.name,
+			"labels": v.labels,
+			"values": values,
+		}).Error("metrics: observation dropped")
+		return nil
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = v.init()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// each calls fn for every series sorted by their label values.
+// Caller must hold v.mu.
+func (v *vec[T]) each(fn func(labels []Label, s *T)) {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		labels := make([]Label, len(v.labels))
+		for i, name := range v.labels {
+			labels[i] = Label{Name: name, Value: v.values[k][i]}
+		}
+		fn(labels, v.series[k])
+	}
+}
+
+// Status returns label value describing outcome of an operation, either "ok" or "error".
+func Status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
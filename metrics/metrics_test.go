@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRegistryWriteTo verifies metrics are written in Prometheus text format.
+func TestRegistryWriteTo(t *testing.T) {
+	counter := NewCounter("requests_total", "Total requests.", "code")
+	counter.Inc("200")
+	counter.Add(2, "200")
+	counter.Inc("500")
+
+	gauge := NewGauge("in_flight", "In flight \"requests\".")
+	gauge.Set(3)
+	gauge.Dec()
+
+	histogram := NewHistogram("duration_seconds", "", []float64{1, 0.5})
+	histogram.Observe(0.2)
+	histogram.Observe(0.7)
+	histogram.Observe(3)
+
+	registry := NewRegistry()
+	registry.Register(counter, gauge, histogram)
+
+	var b strings.Builder
+	if _, err := registry.WriteTo(&b); err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	expected := `# TYPE duration_seconds histogram
+duration_seconds_bucket{le="0.5"} 1
+duration_seconds_bucket{le="1"} 2
+duration_seconds_bucket{le="+Inf"} 3
+duration_seconds_sum 3.9
+duration_seconds_count 3
+# HELP in_flight In flight "requests".
+# TYPE in_flight gauge
+in_flight 2
+# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{code="200"} 3
+requests_total{code="500"} 1
+`
+	if got := b.String(); got != expected {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
+
+// TestLabelsMismatch verifies observations with mismatching label values are dropped.
+func TestLabelsMismatch(t *testing.T) {
+	c := NewCounter("requests_total", "", "code")
+	c.Inc()
+	c.Inc("200", "GET")
+	c.Inc("200")
+
+	f := c.Collect()[0]
+	if got, want := len(f.Samples), 1; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, want := f.Samples[0].Value, float64(1); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DefBuckets are the default histogram buckets tailored to measure latencies in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Counter is a monotonically increasing metric partitioned by labels.
+type Counter struct {
+	vec[float64]
+}
+
+// NewCounter constructs and returns new Counter.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{newVec(name, help, labels, func() *float64 { return new(float64) })}
+}
+
+// Inc increments counter identified by label values by 1.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v to counter identified by label values.
+// Negative values are ignored.
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	if s := c.with(values); s != nil {
+		*s += v
+	}
+	c.mu.Unlock()
+}
+
+// Collect implements Collector.
+func (c *Counter) Collect() []Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := Family{Name: c.name, Help: c.help, Type: CounterType}
+	c.each(func(labels []Label, v *float64) {
+		f.Samples = append(f.Samples, Sample{Labels: labels, Value: *v})
+	})
+	return []Family{f}
+}
+
+// Gauge is a metric that can arbitrarily go up and down partitioned by labels.
+type Gauge struct {
+	vec[float64]
+}
+
+// NewGauge constructs and returns new Gauge.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{newVec(name, help, labels, func() *float64 { return new(float64) })}
+}
+
+// Set sets gauge identified by label values to v.
+func (g *Gauge) Set(v float64, values ...string) {
+	g.mu.Lock()
+	if s := g.with(values); s != nil {
+		*s = v
+	}
+	g.mu.Unlock()
+}
+
+// Add adds v to gauge identified by label values.
+func (g *Gauge) Add(v float64, values ...string) {
+	g.mu.Lock()
+	if s := g.with(values); s != nil {
+		*s += v
+	}
+	g.mu.Unlock()
+}
+
+// Inc increments gauge identified by label values by 1.
+func (g *Gauge) Inc(values ...string) {
+	g.Add(1, values...)
+}
+
+// Dec decrements gauge identified by label values by 1.
+func (g *Gauge) Dec(values ...string) {
+	g.Add(-1, values...)
+}
+
+// Collect implements Collector.
+func (g *Gauge) Collect() []Family {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	f := Family{Name: g.name, Help: g.help, Type: GaugeType}
+	g.each(func(labels []Label, v *float64) {
+		f.Samples = append(f.Samples, Sample{Labels: labels, Value: *v})
+	})
+	return []Family{f}
+}
+
+// histogram holds observations of a single series.
+type histogram struct {
+	counts []uint64 // counts per bucket, non-cumulative
+	count  uint64
+	sum    float64
+}
+
+// Histogram samples observations and counts them in configurable buckets.
+type Histogram struct {
+	vec[histogram]
+	buckets []float64
+}
+
+// NewHistogram constructs and returns new Histogram.
+// If buckets is empty DefBuckets are used.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &Histogram{
+		vec: newVec(name, help, labels, func() *histogram {
+			return &histogram{counts: make([]uint64, len(buckets))}
+		}),
+		buckets: buckets,
+	}
+}
+
+// Observe adds a single observation v to histogram identified by label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.with(values)
+	if s == nil {
+		return
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// ObserveSince observes time elapsed since start in seconds.
+func (h *Histogram) ObserveSince(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+// Collect implements Collector.
+func (h *Histogram) Collect() []Family {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f := Family{Name: h.name, Help: h.help, Type: HistogramType}
+	h.each(func(labels []Label, s *histogram) {
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			f.Samples = append(f.Samples, Sample{
+				Name:   h.name + "_bucket",
+				Labels: append(labels[:len(labels):len(labels)], Label{Name: "le", Value: formatFloat(le)}),
+				Value:  float64(cumulative),
+			})
+		}
+		f.Samples = append(f.Samples,
+			Sample{
+				Name:   h.name + "_bucket",
+				Labels: append(labels[:len(labels):len(labels)], Label{Name: "le", Value: formatFloat(math.Inf(1))}),
+				Value:  float64(s.count),
+			},
+			Sample{Name: h.name + "_sum", Labels: labels, Value: s.sum},
+			Sample{Name: h.name + "_count", Labels: labels, Value: float64(s.count)},
+		)
+	})
+	return []Family{f}
+}
@@ -0,0 +1,231 @@
+// Package pagination implements cursor based pagination primitives.
+//
+// Cursors are opaque to clients: they encode sort order and sort key values of
+// the last item of a page and are signed, so that clients can not forge them.
+package pagination
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// Default request parameters and limits.
+const (
+	DefaultLimit       = 20
+	DefaultMaxLimit    = 100
+	DefaultLimitParam  = "limit"
+	DefaultCursorParam = "cursor"
+)
+
+var (
+	ErrInvalidCursor = errors.New("pagination: invalid cursor")
+	ErrInvalidLimit  = errors.New("pagination: invalid limit")
+)
+
+// Cursor represents position within sorted result set.
+type Cursor struct {
+	Sort string // Sort order Keys belong to
+	Keys []any  // Sort key values of the last item of the previous page
+}
+
+// Page represents requested page.
+type Page struct {
+	Limit int     // Maximum number of items
+	After *Cursor // Position to continue after, nil for the first page
+	Sort  string  // Sort order of items, cursors of the next page are bound to it
+}
+
+// cursorPayload is signed representation of a Cursor.
+type cursorPayload struct {
+	Sort string `json:"s,omitempty"`
+	Keys []any  `json:"k"`
+}
+
+// Option is modifier of a Paginator.
+type Option interface {
+	apply(*Paginator)
+}
+
+// newOption constructs a new option.
+func newOption(fn func(p *Paginator)) *option {
+	return &option{applyFn: fn}
+}
+
+// option is an implementation of Option.
+type option struct {
+	applyFn func(p *Paginator)
+}
+
+// apply implements Option.
+func (o *option) apply(p *Paginator) {
+	o.applyFn(p)
+}
+
+// WithLimits constructs Option setting default and maximum page size.
+func WithLimits(def, max int) Option {
+	return newOption(func(p *Paginator) {
+		p.defaultLimit, p.maxLimit = def, max
+	})
+}
+
+// WithParams constructs Option setting names of limit and cursor query parameters.
+func WithParams(limit, cursor string) Option {
+	return newOption(func(p *Paginator) {
+		p.limitParam, p.cursorParam = limit, cursor
+	})
+}
+
+// Paginator parses page requests and renders page links.
+type Paginator struct {
+	key          []byte
+	defaultLimit int
+	maxLimit     int
+	limitParam   string
+	cursorParam  string
+}
+
+// New constructs and returns new Paginator instance signing cursors with key.
+func New(key []byte, opts ...Option) *Paginator {
+	p := &Paginator{
+		key:          key,
+		defaultLimit: DefaultLimit,
+		maxLimit:     DefaultMaxLimit,
+		limitParam:   DefaultLimitParam,
+		cursorParam:  DefaultCursorParam,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(p)
+		}
+	}
+	return p
+}
+
+// Encode encodes cursor into opaque signed string.
+func (p *Paginator) Encode(c Cursor) (string, error) {
+	payload, err := json.Marshal(cursorPayload{Sort: c.Sort, Keys: c.Keys})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(p.sign(payload)), nil
+}
+
+// Decode decodes and verifies cursor encoded by Encode.
+// Numeric key values are decoded as json.Number to retain their precision.
+func (p *Paginator) Decode(s string) (*Cursor, error) {
+	encoded, signature, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, p.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursorPayload
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{Sort: c.Sort, Keys: c.Keys}, nil
+}
+
+// sign returns signature of payload.
+func (p *Paginator) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Parse parses requested page from request query parameters.
+// Missing limit defaults to default limit, limit exceeding maximum is capped.
+func (p *Paginator) Parse(r *http.Request) (Page, error) {
+	q := r.URL.Query()
+
+	page := Page{Limit: p.defaultLimit}
+	if v := q.Get(p.limitParam); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return Page{}, ErrInvalidLimit
+		}
+		page.Limit = min(limit, p.maxLimit)
+	}
+
+	if v := q.Get(p.cursorParam); v != "" {
+		cursor, err := p.Decode(v)
+		if err != nil {
+			return Page{}, err
+		}
+		page.After = cursor
+	}
+
+	return page, nil
+}
+
+// NextURL returns URL of the next page continuing after keys sorted by page sort order.
+func (p *Paginator) NextURL(r *http.Request, page Page, keys ...any) (string, error) {
+	cursor, err := p.Encode(Cursor{Sort: page.Sort, Keys: keys})
+	if err != nil {
+		return "", err
+	}
+
+	u := url.URL{Path: r.URL.Path}
+	q := r.URL.Query()
+	q.Set(p.limitParam, strconv.Itoa(page.Limit))
+	q.Set(p.cursorParam, cursor)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// SetLink sets Link response header pointing to the next page continuing after keys.
+func (p *Paginator) SetLink(w http.ResponseWriter, r *http.Request, page Page, keys ...any) error {
+	next, err := p.NextURL(r, page, keys...)
+	if err != nil {
+		return err
+	}
+	w.Header().Add("Link", "<"+next+`>; rel="next"`)
+	return nil
+}
+
+// Result is a page of items along with cursor of the next page.
+type Result[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewResult builds page Result out of items fetched with limit one larger than
+// page limit, the extra item only signals existence of the next page.
+// keys returns sort key values of an item, the next cursor is bound to page sort order.
+func NewResult[T any](p *Paginator, page Page, items []T, keys func(item T) []any) (Result[T], error) {
+	result := Result[T]{Items: items}
+	if len(items) <= page.Limit {
+		return result, nil
+	}
+
+	result.Items = items[:page.Limit]
+	next, err := p.Encode(Cursor{Sort: page.Sort, Keys: keys(result.Items[len(result.Items)-1])})
+	if err != nil {
+		return result, err
+	}
+	result.NextCursor = next
+
+	return result, nil
+}
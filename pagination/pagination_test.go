@@ -0,0 +1,63 @@
+package pagination
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// TestCursor verifies cursors survive encoding and can not be tampered with.
+func TestCursor(t *testing.T) {
+	p := New([]byte("secret"))
+
+	encoded, err := p.Encode(Cursor{Sort: "created_at DESC", Keys: []any{"2024-01-01T00:00:00Z", 9007199254740993}})
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+
+	cursor, err := p.Decode(encoded)
+	if err != nil {
+		t.Fatalf("got %v, want %v", err, nil)
+	}
+	if got, want := cursor.Keys[1], json.Number("9007199254740993"); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := cursor.Sort, "created_at DESC"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := New([]byte("other")).Decode(encoded); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("got %v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+// TestParse verifies page request parsing.
+func TestParse(t *testing.T) {
+	p := New([]byte("secret"), WithLimits(10, 50))
+
+	var testcases = []struct {
+		query string
+
+		limit int
+		err   error
+	}{
+		{query: "", limit: 10},
+		{query: "limit=5", limit: 5},
+		{query: "limit=500", limit: 50},
+		{query: "limit=0", err: ErrInvalidLimit},
+		{query: "limit=abc", err: ErrInvalidLimit},
+		{query: "cursor=abc", err: ErrInvalidCursor},
+	}
+
+	for i, tt := range testcases {
+		page, err := p.Parse(httptest.NewRequest("GET", "/items?"+tt.query, nil))
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("#%d got %v, want %v", i, err, tt.err)
+		}
+		if page.Limit != tt.limit {
+			t.Errorf("#%d got %v, want %v", i, page.Limit, tt.limit)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package trace
+
+import (
+	"sync"
+)
+
+// Exporter is any type capable to export finished spans.
+type Exporter interface {
+	Export(span *Span)
+}
+
+// ExporterFunc is an adapter to allow the use of ordinary functions as Exporter.
+type ExporterFunc func(span *Span)
+
+// Export implements Exporter.
+func (f ExporterFunc) Export(span *Span) {
+	f(span)
+}
+
+var (
+	exporter   Exporter   // exporter spans are exported to
+	exporterMu sync.Mutex // guard exporter
+)
+
+// SetExporter sets exporter finished spans are exported to.
+// Nil exporter disables exporting.
+func SetExporter(e Exporter) {
+	exporterMu.Lock()
+	exporter = e
+	exporterMu.Unlock()
+}
+
+// getExporter returns current exporter.
+func getExporter() Exporter {
+	exporterMu.Lock()
+	defer exporterMu.Unlock()
+	return exporter
+}
+
+// InMemoryExporter is Exporter keeping spans in memory, it is useful for tests.
+type InMemoryExporter struct {
+	mu    sync.Mutex // guard fields below
+	spans []*Span
+}
+
+// NewInMemoryExporter constructs and returns new InMemoryExporter instance.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// Export implements Exporter.
+func (e *InMemoryExporter) Export(span *Span) {
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	e.mu.Unlock()
+}
+
+// Spans returns exported spans in the order they were finished.
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+// Reset removes all exported spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	e.spans = nil
+	e.mu.Unlock()
+}
@@ -0,0 +1,134 @@
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/deividaspetraitis/go/errors"
+)
+
+// TraceParentHeader is the W3C Trace Context header name.
+const TraceParentHeader = "traceparent"
+
+// ErrInvalidTraceParent is returned when traceparent value cannot be parsed.
+var ErrInvalidTraceParent = errors.New("trace: invalid traceparent")
+
+// ParseTraceParent parses W3C traceparent value, ie. 00-<trace-id>-<span-id>-<flags>.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, ErrInvalidTraceParent
+	}
+
+	// version 00 must have exactly four parts, future versions may append more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, ErrInvalidTraceParent
+	}
+
+	if err := decodeHex(parts[1], sc.TraceID[:]); err != nil {
+		return sc, err
+	}
+	if err := decodeHex(parts[2], sc.SpanID[:]); err != nil {
+		return sc, err
+	}
+
+	var flags [1]byte
+	if err := decodeHex(parts[3], flags[:]); err != nil {
+		return sc, err
+	}
+	sc.Flags = Flags(flags[0])
+	sc.Remote = true
+
+	if !sc.IsValid() {
+		return sc, ErrInvalidTraceParent
+	}
+
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex string s into dst of exactly matching length.
+func decodeHex(s string, dst []byte) error {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(dst, []byte(s)); err != nil {
+		return ErrInvalidTraceParent
+	}
+	return nil
+}
+
+// TraceParent returns W3C traceparent representation of span context.
+func (sc SpanContext) TraceParent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{byte(sc.Flags)})
+}
+
+// Inject sets traceparent header of h from the span context found in ctx.
+func Inject(ctx context.Context, h http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		h.Set(TraceParentHeader, sc.TraceParent())
+	}
+}
+
+// Extract returns a copy of ctx carrying remote span context parsed from h.
+// If h carries no valid traceparent ctx is returned unchanged.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	sc, err := ParseTraceParent(h.Get(TraceParentHeader))
+	if err != nil {
+		return ctx
+	}
+	return ContextWithSpanContext(ctx, sc)
+}
+
+// InjectMetadata stores span context found in ctx under traceparent key of JSON object md.
+// Empty md results in a new JSON object, md not being JSON object or already carrying
+// traceparent is returned unchanged.
+func InjectMetadata(ctx context.Context, md []byte) []byte {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return md
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if len(md) > 0 {
+		if err := json.Unmarshal(md, &fields); err != nil {
+			return md
+		}
+	}
+	if _, ok := fields[TraceParentHeader]; ok {
+		return md // keep trace context the metadata was produced within
+	}
+
+	value, err := json.Marshal(sc.TraceParent())
+	if err != nil {
+		return md
+	}
+	fields[TraceParentHeader] = value
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return md
+	}
+	return b
+}
+
+// ExtractMetadata returns a copy of ctx carrying remote span context parsed from JSON object md.
+// If md carries no valid traceparent ctx is returned unchanged.
+func ExtractMetadata(ctx context.Context, md []byte) context.Context {
+	var fields struct {
+		TraceParent string `json:"traceparent"`
+	}
+	if err := json.Unmarshal(md, &fields); err != nil {
+		return ctx
+	}
+
+	sc, err := ParseTraceParent(fields.TraceParent)
+	if err != nil {
+		return ctx
+	}
+	return ContextWithSpanContext(ctx, sc)
+}
@@ -0,0 +1,226 @@
+// Package trace implements lightweight distributed tracing compatible with
+// W3C Trace Context propagation.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID is a unique identity of a trace.
+type TraceID [16]byte
+
+// IsValid reports whether the trace ID is valid, ie. not all zeros.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// String returns hex encoded representation of the trace ID.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID is a unique identity of a span within a trace.
+type SpanID [8]byte
+
+// IsValid reports whether the span ID is valid, ie. not all zeros.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// String returns hex encoded representation of the span ID.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// Flags are trace flags as defined by W3C Trace Context.
+type Flags byte
+
+// FlagsSampled indicates the trace is sampled.
+const FlagsSampled Flags = 0x01
+
+// SpanContext identifies a span and is propagated across process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   Flags
+	Remote  bool // whether span context was propagated from a remote parent
+}
+
+// IsValid reports whether span context has valid trace and span IDs.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Kind describes the relationship between the span and its parent.
+type Kind int
+
+// Supported span kinds.
+const (
+	KindInternal Kind = iota
+	KindServer
+	KindClient
+	KindProducer
+	KindConsumer
+)
+
+// Span represents a single operation within a trace.
+type Span struct {
+	Name        string
+	Kind        Kind
+	SpanContext SpanContext
+	Parent      SpanContext
+	Start       time.Time
+	End         time.Time
+	Attributes  map[string]string
+	Err         error
+
+	mu       sync.Mutex // guard fields below
+	ended    bool
+	exporter Exporter
+}
+
+// SetAttribute sets attribute key to value.
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]string)
+	}
+	s.Attributes[key] = value
+}
+
+// SetError records err as the span outcome.
+func (s *Span) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.Err = err
+}
+
+// Finish marks the span as complete and exports it.
+// Calls after the first one are ignored.
+func (s *Span) Finish() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	if s.exporter != nil {
+		s.exporter.Export(s)
+	}
+}
+
+// StartOption is modifier of a span being started.
+type StartOption interface {
+	apply(*Span)
+}
+
+// newStartOption constructs a new startOption.
+func newStartOption(fn func(s *Span)) *startOption {
+	return &startOption{applyFn: fn}
+}
+
+// startOption is an implementation of StartOption.
+type startOption struct {
+	applyFn func(s *Span)
+}
+
+// apply implements StartOption.
+func (o *startOption) apply(s *Span) {
+	o.applyFn(s)
+}
+
+// WithKind constructs StartOption setting span kind.
+func WithKind(kind Kind) StartOption {
+	return newStartOption(func(s *Span) {
+		s.Kind = kind
+	})
+}
+
+// WithAttribute constructs StartOption setting span attribute.
+func WithAttribute(key, value string) StartOption {
+	return newStartOption(func(s *Span) {
+		if s.Attributes == nil {
+			s.Attributes = make(map[string]string)
+		}
+		s.Attributes[key] = value
+	})
+}
+
+// Start starts a new span as a child of the span found in ctx, if any.
+// Returned context carries the new span.
+// Caller must call Span.Finish once the operation completes.
+func Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+
+	span := &Span{
+		Name:     name,
+		Parent:   parent,
+		Start:    time.Now(),
+		exporter: getExporter(),
+	}
+
+	if parent.IsValid() {
+		span.SpanContext.TraceID = parent.TraceID
+		span.SpanContext.Flags = parent.Flags
+	} else {
+		rand.Read(span.SpanContext.TraceID[:])
+		span.SpanContext.Flags = FlagsSampled
+	}
+	rand.Read(span.SpanContext.SpanID[:])
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(span)
+		}
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// contextKey is a type of keys used to store values in context.
+type contextKey int
+
+// spanKey is the context key current span or remote span context is stored under.
+const spanKey contextKey = iota
+
+// ContextWithSpan returns a copy of ctx carrying span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey, span)
+}
+
+// ContextWithSpanContext returns a copy of ctx carrying span context, usually of a remote parent.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey, sc)
+}
+
+// SpanFromContext returns span stored in ctx or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns span context of the current span or remote
+// span context found in ctx, whichever was stored last.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	switch v := ctx.Value(spanKey).(type) {
+	case *Span:
+		return v.SpanContext
+	case SpanContext:
+		return v
+	}
+	return SpanContext{}
+}
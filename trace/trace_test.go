@@ -0,0 +1,71 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// TestParseTraceParent verifies traceparent parsing.
+func TestParseTraceParent(t *testing.T) {
+	var testcases = []struct {
+		input string
+
+		valid bool
+	}{
+		{input: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", valid: true},
+		{input: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", valid: true},
+		{input: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{input: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{input: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{input: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{input: "00-4bf92f3577b34da6-00f067aa0ba902b7-01"},
+		{input: ""},
+	}
+
+	for i, tt := range testcases {
+		sc, err := ParseTraceParent(tt.input)
+		if (err == nil) != tt.valid {
+			t.Fatalf("#%d got %v, want valid %v", i, err, tt.valid)
+		}
+		if tt.valid && sc.TraceParent()[3:] != tt.input[3:55] {
+			t.Errorf("#%d got %v, want %v", i, sc.TraceParent(), tt.input)
+		}
+	}
+}
+
+// TestPropagation verifies span context survives header and metadata round trips.
+func TestPropagation(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	SetExporter(exporter)
+	defer SetExporter(nil)
+
+	ctx, span := Start(context.Background(), "parent")
+
+	h := make(http.Header)
+	Inject(ctx, h)
+	if got := SpanContextFromContext(Extract(context.Background(), h)); got.TraceID != span.SpanContext.TraceID || got.SpanID != span.SpanContext.SpanID {
+		t.Errorf("got %v, want %v", got, span.SpanContext)
+	}
+
+	md := InjectMetadata(ctx, []byte(`{"user":"test"}`))
+	child, childSpan := Start(ExtractMetadata(context.Background(), md), "child")
+	if childSpan.SpanContext.TraceID != span.SpanContext.TraceID || childSpan.Parent.SpanID != span.SpanContext.SpanID {
+		t.Errorf("got %v, want parent %v", childSpan.Parent, span.SpanContext)
+	}
+	if SpanFromContext(child) != childSpan {
+		t.Errorf("got %v, want %v", SpanFromContext(child), childSpan)
+	}
+
+	if got := InjectMetadata(child, md); string(got) != string(md) {
+		t.Errorf("got %s, want %s", got, md)
+	}
+
+	childSpan.Finish()
+	span.Finish()
+	span.Finish()
+
+	if got, want := len(exporter.Spans()), 2; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}